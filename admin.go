@@ -0,0 +1,66 @@
+package dodod
+
+import (
+	"context"
+	"github.com/dgraph-io/badger/v2"
+)
+
+// compactWorkers is the number of goroutines flattening the LSM tree
+const compactWorkers = 2
+
+// compactDiscardRatio is the ratio of stale data which makes
+// a value log file worth rewriting
+const compactDiscardRatio = 0.5
+
+// Count returns the number of documents of the document type stored
+// in the database, an empty document type counts every document.
+// Documents of types which are not registered are counted as well
+func (db *Database) Count(docType string) (uint64, error) {
+	return db.CountContext(context.Background(), docType)
+}
+
+// CountContext is the context aware variant of Count
+func (db *Database) CountContext(ctx context.Context, docType string) (uint64, error) {
+	if !db.IsDatabaseReady() {
+		return 0, ErrDatabaseIsNotOpen
+	}
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	var total uint64
+	_, err := db.iterateRaw(ctx, txn, docType, nil, func(key []byte, value []byte) error {
+		total = total + 1
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// Compact flattens the LSM tree of the database and rewrites the value log
+// files holding mostly stale data to reclaim disk space
+func (db *Database) Compact() error {
+	if !db.IsDatabaseReady() {
+		return ErrDatabaseIsNotOpen
+	}
+
+	if db.isReadOnly {
+		return ErrDatabaseIsReadOnly
+	}
+
+	if err := db.internalDb.Flatten(compactWorkers); err != nil {
+		return err
+	}
+
+	for {
+		err := db.internalDb.RunValueLogGC(compactDiscardRatio)
+		if err == badger.ErrNoRewrite {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
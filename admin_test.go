@@ -0,0 +1,60 @@
+package dodod
+
+import (
+	"testing"
+)
+
+func TestDatabase_CountCompact(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.Count(""); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.Compact(); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&MyTestDocument{Id: "2", Name: "second"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.PutRaw("Note", "3", []byte(`{"title":"note"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if total, err := db.Count(""); err != nil || total != 3 {
+		t.Fatalf("unexpected count %d: %v", total, err)
+	}
+	if total, err := db.Count("MyTestDocument"); err != nil || total != 2 {
+		t.Fatalf("unexpected count %d: %v", total, err)
+	}
+
+	if err := db.Delete([]interface{}{&MyTestDocument{Id: "1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Compact(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total, _ := db.Count(""); total != 2 {
+		t.Fatalf("compaction should keep the documents, found %d", total)
+	}
+}
@@ -0,0 +1,254 @@
+package dodod
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backupFormat is the version of the backup archive layout
+const backupFormat = 1
+
+// backupMaxPendingWrites is the number of pending writes while loading a backup
+const backupMaxPendingWrites = 256
+
+// backupStagingPattern is the name of the file staging the badger backup stream
+const backupStagingPattern = "backup-*.staging"
+
+const (
+	backupManifestEntry = "manifest.json"
+	backupConfigEntry   = "dodod.json"
+	backupDatabaseEntry = "database.backup"
+)
+
+// BackupManifest describes a backup archive
+type BackupManifest struct {
+	Format    int       `json:"format"`
+	Since     uint64    `json:"since"`
+	Version   uint64    `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// IsIncremental reports if the backup contains only the changes since a version
+func (m *BackupManifest) IsIncremental() bool {
+	return m.Since > 0
+}
+
+// contextReader stops reading once the context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// Backup writes a full backup of the database into the writer, it returns
+// the version to pass to BackupSince for the next incremental backup.
+//
+// The archive is a tar stream holding a manifest, the configuration and a
+// badger backup stream of a consistent snapshot. The index store is not
+// included, Restore rebuilds it from the documents. The documents are
+// stored decrypted, the archive must be protected by the caller
+func (db *Database) Backup(w io.Writer) (uint64, error) {
+	return db.BackupSinceContext(context.Background(), w, 0)
+}
+
+// BackupSince writes an incremental backup of the changes written at or
+// after the version returned by a previous backup, zero means a full backup
+func (db *Database) BackupSince(w io.Writer, since uint64) (uint64, error) {
+	return db.BackupSinceContext(context.Background(), w, since)
+}
+
+// BackupSinceContext is the context aware variant of BackupSince
+func (db *Database) BackupSinceContext(ctx context.Context, w io.Writer, since uint64) (uint64, error) {
+	if !db.IsDatabaseReady() {
+		return 0, ErrDatabaseIsNotOpen
+	}
+
+	config, err := ioutil.ReadFile(filepath.Join(db.dbPath, "dodod.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	// the size of a tar entry must be known before its content, so the
+	// badger stream is staged in a file only readable by the owner. It is
+	// kept inside the database path since it holds the decrypted documents
+	staged, err := ioutil.TempFile(db.dbPath, backupStagingPattern)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = staged.Close()
+		_ = os.Remove(staged.Name())
+	}()
+
+	version, err := db.internalDb.Backup(staged, since)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	size, err := staged.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := staged.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	manifest, err := json.Marshal(&BackupManifest{
+		Format:    backupFormat,
+		Since:     since,
+		Version:   version,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	tw := tar.NewWriter(w)
+	writeEntry := func(name string, size int64, r io.Reader) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    size,
+			ModTime: time.Now(),
+		}); err != nil {
+			return err
+		}
+		_, err := io.Copy(tw, &contextReader{ctx: ctx, r: r})
+		return err
+	}
+
+	if err := writeEntry(backupManifestEntry, int64(len(manifest)), bytes.NewReader(manifest)); err != nil {
+		return 0, err
+	}
+
+	if err := writeEntry(backupConfigEntry, int64(len(config)), bytes.NewReader(config)); err != nil {
+		return 0, err
+	}
+
+	if err := writeEntry(backupDatabaseEntry, size, staged); err != nil {
+		return 0, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// Restore restores the backup archive into the path and leaves the
+// database open on it. A full backup requires a path without a database,
+// an incremental backup is applied on top of the database at the path.
+//
+// The database must not be open, its password and registered documents
+// are used to open the restored database and to rebuild its index store
+func (db *Database) Restore(r io.Reader, path string) error {
+	return db.RestoreContext(context.Background(), r, path)
+}
+
+// RestoreContext is the context aware variant of Restore
+func (db *Database) RestoreContext(ctx context.Context, r io.Reader, path string) error {
+	if db.IsDatabaseReady() {
+		return ErrDatabaseIsOpen
+	}
+
+	if path == "" {
+		return ErrEmptyPath
+	}
+
+	tr := tar.NewReader(&contextReader{ctx: ctx, r: r})
+
+	manifest := &BackupManifest{}
+	if err := nextBackupEntry(tr, backupManifestEntry); err != nil {
+		return err
+	}
+	if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+		return ErrInvalidBackup
+	}
+	if manifest.Format != backupFormat {
+		return ErrInvalidBackup
+	}
+
+	if err := nextBackupEntry(tr, backupConfigEntry); err != nil {
+		return err
+	}
+
+	db.SetDbPath(path)
+	exists := db.isDbExists()
+
+	if manifest.IsIncremental() && !exists {
+		return ErrDatabaseNotFound
+	}
+
+	if !manifest.IsIncremental() {
+		if exists {
+			return ErrDatabaseExists
+		}
+
+		if err := os.MkdirAll(path, os.FileMode(0700)); err != nil {
+			return err
+		}
+
+		config, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(path, "dodod.json"), config, 0700); err != nil {
+			return err
+		}
+	}
+
+	if err := nextBackupEntry(tr, backupDatabaseEntry); err != nil {
+		return err
+	}
+
+	if err := db.Open(); err != nil {
+		return err
+	}
+
+	if err := db.internalDb.Load(tr, backupMaxPendingWrites); err != nil {
+		_ = db.Close()
+		return err
+	}
+
+	if _, err := db.ReindexContext(ctx, nil); err != nil {
+		_ = db.Close()
+		return err
+	}
+
+	return nil
+}
+
+// nextBackupEntry advances the archive to the next entry which must have the name
+func nextBackupEntry(tr *tar.Reader, name string) error {
+	header, err := tr.Next()
+	if err == io.EOF {
+		return ErrInvalidBackup
+	} else if err != nil {
+		return err
+	}
+
+	if header.Name != name {
+		return ErrInvalidBackup
+	}
+
+	return nil
+}
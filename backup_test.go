@@ -0,0 +1,140 @@
+package dodod
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func newBackupTestDatabase(t *testing.T, path string) *Database {
+	t.Helper()
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(path)
+	db.SetDbPassword("secret")
+
+	if err := db.RegisterDocument(&IndexedTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return db
+}
+
+func TestDatabase_BackupRestore(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	restorePath := "/tmp/dodod-restore"
+	defer cleanupDb(t, dbPath)
+	defer cleanupDb(t, restorePath)
+
+	db := newBackupTestDatabase(t, dbPath)
+
+	if _, err := db.Backup(&bytes.Buffer{}); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{
+		&IndexedTestDocument{Id: "1", Email: "one@example.com", City: "dhaka"},
+		&IndexedTestDocument{Id: "2", Email: "two@example.com", City: "dhaka"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	full := &bytes.Buffer{}
+	version, err := db.Backup(full)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version == 0 {
+		t.Fatalf("backup should return a version")
+	}
+
+	// the staged stream is removed when the writer fails
+	if _, err := db.Backup(&failingWriter{}); err == nil {
+		t.Fatalf("backup should fail")
+	}
+	if staged, _ := filepath.Glob(filepath.Join(dbPath, backupStagingPattern)); len(staged) != 0 {
+		t.Fatalf("staged backup should be removed, found: %v", staged)
+	}
+
+	if err := db.Update([]interface{}{
+		&IndexedTestDocument{Id: "2", Email: "two@example.com", City: "paris"},
+		&IndexedTestDocument{Id: "3", Email: "three@example.com", City: "paris"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.Delete([]interface{}{&IndexedTestDocument{Id: "1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	incremental := &bytes.Buffer{}
+	if _, err := db.BackupSince(incremental, version); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Restore(bytes.NewReader(full.Bytes()), restorePath); err != ErrDatabaseIsOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = db.Close()
+
+	restored := newBackupTestDatabase(t, restorePath)
+
+	if err := restored.Restore(bytes.NewReader(incremental.Bytes()), restorePath); err != ErrDatabaseNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := restored.Restore(bytes.NewReader(full.Bytes()), restorePath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if total, _, _ := restored.Read([]string{"1", "2", "3"}); total != 2 {
+		t.Fatalf("full backup should contain two documents, found %d", total)
+	}
+	if total, _ := searchTotal(t, restored, "dhaka"); total != 2 {
+		t.Fatalf("index store should be rebuilt, found %d", total)
+	}
+	if total, _, _ := restored.FindBy("email", "one@example.com"); total != 1 {
+		t.Fatalf("secondary indexes should be restored")
+	}
+	_ = restored.Close()
+
+	if err := restored.Restore(bytes.NewReader(full.Bytes()), restorePath); err != ErrDatabaseExists {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := restored.Restore(bytes.NewReader(incremental.Bytes()), restorePath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if restored.IsDocumentExists("1") || restored.IsIndexExists("1") {
+		t.Fatalf("incremental backup should delete the document")
+	}
+	if total, _ := searchTotal(t, restored, "paris"); total != 2 {
+		t.Fatalf("incremental backup should update the index store, found %d", total)
+	}
+	_ = restored.Close()
+
+	wrong := newBackupTestDatabase(t, restorePath)
+	wrong.SetDbPassword("wrong")
+	if err := wrong.Restore(bytes.NewReader(incremental.Bytes()), restorePath); err != ErrWrongPassword {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := wrong.Restore(bytes.NewReader([]byte("not an archive")), restorePath); err == nil {
+		t.Fatalf("invalid archive should fail")
+	}
+}
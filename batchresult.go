@@ -0,0 +1,221 @@
+package dodod
+
+import (
+	"context"
+	"fmt"
+)
+
+// BatchOptions controls how the batch mutation variants
+// treat invalid elements
+type BatchOptions struct {
+	// SkipInvalid commits the valid elements and reports the invalid ones,
+	// otherwise nothing is committed if any element is invalid
+	SkipInvalid bool
+}
+
+// BatchItemError reports an element of a batch which could not be used
+type BatchItemError struct {
+	// Index is the position of the element in the batch
+	Index int
+
+	// Id is the id of the element, empty if it has none
+	Id string
+
+	Err error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("dodod: batch element %d with id `%s`: %v", e.Index, e.Id, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchResult reports the outcome of a batch mutation
+type BatchResult struct {
+	// Committed is the number of elements committed
+	Committed uint64
+
+	// Errors are the invalid elements and the elements rejected while
+	// writing, such as a unique constraint violation, in the order of the batch
+	Errors []*BatchItemError
+}
+
+// HasErrors reports if any element of the batch was invalid or rejected
+func (r *BatchResult) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// CreateWithResult is the variant of Create which validates every element
+// and reports the invalid ones. An element is invalid if it is not a
+// Document, has an empty or reserved id, its type is not registered or it
+// can not be encoded. An element violating a unique constraint is reported
+// the same way
+func (db *Database) CreateWithResult(data []interface{}, opts *BatchOptions) (*BatchResult, error) {
+	return db.CreateWithResultContext(context.Background(), data, opts)
+}
+
+// CreateWithResultContext is the context aware variant of CreateWithResult
+func (db *Database) CreateWithResultContext(ctx context.Context, data []interface{}, opts *BatchOptions) (*BatchResult, error) {
+	return db.batchWithResult(ctx, data, opts, false)
+}
+
+// UpdateWithResult is the variant of Update which validates every element
+// and reports the invalid ones the same way as CreateWithResult
+func (db *Database) UpdateWithResult(data []interface{}, opts *BatchOptions) (*BatchResult, error) {
+	return db.UpdateWithResultContext(context.Background(), data, opts)
+}
+
+// UpdateWithResultContext is the context aware variant of UpdateWithResult
+func (db *Database) UpdateWithResultContext(ctx context.Context, data []interface{}, opts *BatchOptions) (*BatchResult, error) {
+	return db.batchWithResult(ctx, data, opts, false)
+}
+
+// DeleteWithResult is the variant of Delete which validates every element
+// and reports the ones which are not a Document or have an empty or reserved id
+func (db *Database) DeleteWithResult(data []interface{}, opts *BatchOptions) (*BatchResult, error) {
+	return db.DeleteWithResultContext(context.Background(), data, opts)
+}
+
+// DeleteWithResultContext is the context aware variant of DeleteWithResult
+func (db *Database) DeleteWithResultContext(ctx context.Context, data []interface{}, opts *BatchOptions) (*BatchResult, error) {
+	return db.batchWithResult(ctx, data, opts, true)
+}
+
+// batchWithResult validates the elements and stores or removes the valid
+// ones inside one transaction. The elements are checked against the
+// transaction before they are written, so an element rejected while writing
+// is reported along with the invalid ones. Invalid elements result in
+// ErrInvalidBatch unless they are skipped, errors of the database and the
+// index store are returned as they are
+func (db *Database) batchWithResult(ctx context.Context,
+	data []interface{},
+	opts *BatchOptions,
+	remove bool) (*BatchResult, error) {
+
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+
+	internalBatchTxn := db.internalDb.NewTransaction(true)
+	defer internalBatchTxn.Discard()
+
+	result := &BatchResult{Errors: make([]*BatchItemError, 0)}
+	batch := db.internalIndex.NewBatch()
+	keys := make([]string, 0, len(data))
+	for i, d := range data {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		id, encoded, err := db.validateBatchItem(d, !remove)
+		if err != nil {
+			result.Errors = append(result.Errors, &BatchItemError{Index: i, Id: id, Err: err})
+			continue
+		}
+
+		key := db.documentKey(d.(Document))
+		if remove {
+			if err := db.removeDocument(internalBatchTxn, key); err != nil {
+				return result, err
+			}
+			batch.Delete(key)
+			keys = append(keys, key)
+			continue
+		}
+
+		version, err := db.nextVersion(internalBatchTxn, key)
+		if err != nil {
+			return result, err
+		}
+
+		write, err := db.prepareEncodedDocumentWrite(internalBatchTxn, key, d, withVersion(encoded, version), version)
+		if err != nil {
+			result.Errors = append(result.Errors, &BatchItemError{Index: i, Id: id, Err: err})
+			continue
+		}
+
+		if err := db.writeDocument(internalBatchTxn, write); err != nil {
+			return result, err
+		}
+
+		if err := batch.Index(key, indexValue(d)); err != nil {
+			return result, err
+		}
+		keys = append(keys, key)
+	}
+
+	if result.HasErrors() && !opts.SkipInvalid {
+		return result, ErrInvalidBatch
+	}
+
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	intentKey, err := db.writeIndexIntent(internalBatchTxn, keys)
+	if err != nil {
+		return result, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	if err := internalBatchTxn.Commit(); err != nil {
+		return result, commitError(err)
+	}
+
+	result.Committed = uint64(len(keys))
+
+	// the intent stays in the database on failure and
+	// will be replayed by ReplayIntentLog
+	if err := db.internalIndex.Batch(batch); err != nil {
+		return result, ErrIndexStoreTransactionFailed
+	}
+
+	db.clearIndexIntent(intentKey)
+
+	return result, nil
+}
+
+// validateBatchItem checks an element of a batch and returns its id,
+// with encode the element is encoded without a version
+func (db *Database) validateBatchItem(d interface{}, encode bool) (string, []byte, error) {
+	n, ok := d.(Document)
+	if !ok {
+		return "", nil, ErrInvalidDocument
+	}
+
+	id := n.GetId()
+	if id == "" {
+		return id, nil, ErrIdCanNotBeEmpty
+	}
+
+	if isInternalKey([]byte(db.documentKey(n))) {
+		return id, nil, ErrIdIsReserved
+	}
+
+	if !encode {
+		return id, nil, nil
+	}
+
+	if _, registered := db.documentRegistryCache[n.Type()]; !registered {
+		return id, nil, ErrDocumentTypeIsNotRegistered
+	}
+
+	encoded, err := db.EncodeDocument(d)
+	if err != nil {
+		return id, nil, err
+	}
+
+	return id, encoded, nil
+}
@@ -0,0 +1,153 @@
+package dodod
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDatabase_CreateWithResult(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.CreateWithResult(nil, nil); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	data := []interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&MyTestDocument{Name: "no id"},
+		"not a document",
+		&MyTestDocument2{Id: "2"},
+		&MyTestDocument{Id: "3", Name: "third"},
+	}
+
+	result, err := db.CreateWithResult(data, nil)
+	if err != ErrInvalidBatch {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Committed != 0 || len(result.Errors) != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if db.IsDocumentExists("1") {
+		t.Fatalf("nothing should be committed without SkipInvalid")
+	}
+
+	expected := []struct {
+		index int
+		id    string
+		err   error
+	}{
+		{1, "", ErrIdCanNotBeEmpty},
+		{2, "", ErrInvalidDocument},
+		{3, "2", ErrDocumentTypeIsNotRegistered},
+	}
+	for i, e := range expected {
+		item := result.Errors[i]
+		if item.Index != e.index || item.Id != e.id || !errors.Is(item, e.err) {
+			t.Fatalf("unexpected error at %d: %v", i, item)
+		}
+	}
+
+	result, err = db.CreateWithResult(data, &BatchOptions{SkipInvalid: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Committed != 2 || !result.HasErrors() {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if !db.IsDocumentExists("1") || !db.IsDocumentExists("3") || !db.IsIndexExists("3") {
+		t.Fatalf("valid elements should be committed")
+	}
+
+	result, err = db.UpdateWithResult([]interface{}{
+		&MyTestDocument{Id: "1", Name: "changed"},
+		&MyTestDocument{},
+	}, &BatchOptions{SkipInvalid: true})
+	if err != nil || result.Committed != 1 || len(result.Errors) != 1 || result.Errors[0].Index != 1 {
+		t.Fatalf("unexpected result: %+v %v", result, err)
+	}
+	if _, docs, _ := db.Read([]string{"1"}); docs[0].(*MyTestDocument).Name != "changed" {
+		t.Fatalf("valid element should be updated")
+	}
+
+	result, err = db.DeleteWithResult([]interface{}{
+		&MyTestDocument{Id: "\x00dodod/intent/x"},
+		&MyTestDocument{Id: "3"},
+	}, &BatchOptions{SkipInvalid: true})
+	if err != nil || result.Committed != 1 || !errors.Is(result.Errors[0], ErrIdIsReserved) {
+		t.Fatalf("unexpected result: %+v %v", result, err)
+	}
+	if db.IsDocumentExists("3") {
+		t.Fatalf("valid element should be deleted")
+	}
+}
+
+func TestDatabase_CreateWithResultUniqueConstraint(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&IndexedTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.Create([]interface{}{&IndexedTestDocument{Id: "1", Email: "one@example.com"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := []interface{}{
+		&IndexedTestDocument{Id: "2", Email: "two@example.com"},
+		&IndexedTestDocument{Id: "3", Email: "one@example.com"},
+		&IndexedTestDocument{Id: "4", Email: "two@example.com"},
+	}
+
+	result, err := db.CreateWithResult(data, nil)
+	if err != ErrInvalidBatch || result.Committed != 0 || len(result.Errors) != 2 {
+		t.Fatalf("unexpected result: %+v %v", result, err)
+	}
+	if result.Errors[0].Index != 1 || result.Errors[0].Id != "3" || !errors.Is(result.Errors[0], ErrUniqueConstraintViolation) {
+		t.Fatalf("unexpected error: %v", result.Errors[0])
+	}
+	if result.Errors[1].Index != 2 || result.Errors[1].Id != "4" || !errors.Is(result.Errors[1], ErrUniqueConstraintViolation) {
+		t.Fatalf("unexpected error: %v", result.Errors[1])
+	}
+	if db.IsDocumentExists("2") {
+		t.Fatalf("nothing should be committed without SkipInvalid")
+	}
+
+	result, err = db.CreateWithResult(data, &BatchOptions{SkipInvalid: true})
+	if err != nil || result.Committed != 1 || len(result.Errors) != 2 {
+		t.Fatalf("unexpected result: %+v %v", result, err)
+	}
+	if total, docs, _ := db.FindBy("email", "two@example.com"); total != 1 || docs[0].(*IndexedTestDocument).Id != "2" {
+		t.Fatalf("valid element should be committed, found %d", total)
+	}
+	if db.IsDocumentExists("3") || db.IsDocumentExists("4") {
+		t.Fatalf("rejected elements should be skipped")
+	}
+}
@@ -0,0 +1,329 @@
+package dodod
+
+import (
+	"context"
+	"github.com/dgraph-io/badger/v2"
+)
+
+// DefaultBulkChunkSize is the maximum number of documents per bulk chunk
+const DefaultBulkChunkSize = 1000
+
+// DefaultBulkChunkBytes is the maximum encoded size of a bulk chunk
+const DefaultBulkChunkBytes = 4 << 20
+
+// BulkOptions controls how BulkWrite splits the documents into chunks
+type BulkOptions struct {
+	// ChunkSize is the maximum number of documents per chunk,
+	// zero means DefaultBulkChunkSize
+	ChunkSize int
+
+	// ChunkBytes is the maximum encoded size of the documents per chunk,
+	// a larger document is written in a chunk of its own,
+	// zero means DefaultBulkChunkBytes
+	ChunkBytes int
+
+	// Progress is called after every chunk
+	Progress func(chunk *BulkChunk)
+}
+
+// BulkChunk is a range of the documents written by BulkWrite
+type BulkChunk struct {
+	// Start is the position of the first document of the chunk
+	Start int
+
+	// End is the position after the last document of the chunk
+	End int
+
+	// Err is the reason the chunk failed, nil if it was written. A failed
+	// chunk is not counted as written even if part of it was stored
+	Err error
+}
+
+// BulkResult reports the chunks written by BulkWrite
+type BulkResult struct {
+	// Written is the number of documents written
+	Written uint64
+
+	// Chunks are the chunks in the order they were processed,
+	// only the last chunk may have failed
+	Chunks []*BulkChunk
+}
+
+// Resume returns the position of the first document which was not written
+func (r *BulkResult) Resume() int {
+	for _, chunk := range r.Chunks {
+		if chunk.Err != nil {
+			return chunk.Start
+		}
+	}
+
+	if len(r.Chunks) == 0 {
+		return 0
+	}
+
+	return r.Chunks[len(r.Chunks)-1].End
+}
+
+type bulkEntry struct {
+	key string
+	doc interface{}
+}
+
+// BulkWrite stores and indexes a large number of documents by splitting
+// them into chunks, every chunk is committed on its own so a large import
+// never exceeds the transaction size of the database. Existing documents
+// are replaced the same way as Upsert.
+//
+// Chunks of document types without secondary indexes are written using a
+// badger WriteBatch, other chunks use a transaction to keep the unique
+// constraints. Writing stops at the first failed chunk, the result tells
+// which documents were written and where to resume.
+//
+// A WriteBatch does not detect conflicts, the versions of its documents are
+// not coordinated with concurrent writes to the same keys such as
+// UpdateIfVersion. Documents which are updated concurrently should not be
+// written by BulkWrite.
+//
+// A chunk written using a WriteBatch is not atomic, a failed chunk may be
+// partly stored in the database. Resume returns the start of the failed
+// chunk so resuming writes those documents again, the index intent of the
+// chunk is replayed by ReplayIntentLog when the database is opened
+func (db *Database) BulkWrite(data []interface{}, opts *BulkOptions) (*BulkResult, error) {
+	return db.BulkWriteContext(context.Background(), data, opts)
+}
+
+// BulkWriteContext is the context aware variant of BulkWrite,
+// chunks written before the context is done are kept
+func (db *Database) BulkWriteContext(ctx context.Context, data []interface{}, opts *BulkOptions) (*BulkResult, error) {
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	if db.isReadOnly {
+		return nil, ErrDatabaseIsReadOnly
+	}
+
+	if opts == nil {
+		opts = &BulkOptions{}
+	}
+
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultBulkChunkSize
+	}
+
+	chunkBytes := opts.ChunkBytes
+	if chunkBytes <= 0 {
+		chunkBytes = DefaultBulkChunkBytes
+	}
+
+	result := &BulkResult{Chunks: make([]*BulkChunk, 0)}
+
+	flush := func(start int, entries []*bulkEntry) error {
+		chunk := &BulkChunk{Start: start, End: start + len(entries)}
+		chunk.Err = db.writeBulkChunk(ctx, entries)
+		result.Chunks = append(result.Chunks, chunk)
+
+		if chunk.Err == nil {
+			result.Written = result.Written + uint64(len(entries))
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(chunk)
+		}
+
+		return chunk.Err
+	}
+
+	start := 0
+	size := 0
+	entries := make([]*bulkEntry, 0, chunkSize)
+	for i, d := range data {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		n, ok := d.(Document)
+		if !ok {
+			return result, ErrInvalidDocument
+		}
+
+		if n.GetId() == "" {
+			return result, ErrIdCanNotBeEmpty
+		}
+
+		encoded, err := db.EncodeDocument(d)
+		if err != nil {
+			return result, err
+		}
+
+		if len(entries) > 0 && (len(entries) >= chunkSize || size+len(encoded) > chunkBytes) {
+			if err := flush(start, entries); err != nil {
+				return result, err
+			}
+			start = i
+			size = 0
+			entries = make([]*bulkEntry, 0, chunkSize)
+		}
+
+		entries = append(entries, &bulkEntry{key: db.documentKey(n), doc: d})
+		size = size + len(encoded)
+	}
+
+	if len(entries) > 0 {
+		if err := flush(start, entries); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// writeBulkChunk commits the chunk to the database and the index store
+func (db *Database) writeBulkChunk(ctx context.Context, entries []*bulkEntry) error {
+	docs := make([]interface{}, 0, len(entries))
+	blind := true
+	for _, entry := range entries {
+		if len(db.secondaryIndexRegistryCache[entry.doc.(Document).Type()]) > 0 {
+			blind = false
+		}
+		docs = append(docs, entry.doc)
+	}
+
+	if !blind {
+		return db.UpdateContext(ctx, docs)
+	}
+
+	return db.writeBulkChunkBlind(ctx, entries)
+}
+
+// writeBulkChunkBlind writes the chunk using a badger WriteBatch,
+// versions and stale secondary index entries are read from a snapshot.
+// A key written more than once in the chunk keeps its last document.
+// Every document is checked and encoded before the first write, since a
+// WriteBatch commits on its own once it grows too big and canceling it
+// does not roll back what was committed
+func (db *Database) writeBulkChunkBlind(ctx context.Context, entries []*bulkEntry) error {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
+	snapshot := db.internalDb.NewTransaction(false)
+	defer snapshot.Discard()
+
+	type blindWrite struct {
+		entry     *bulkEntry
+		encoded   []byte
+		version   uint64
+		created   bool
+		staleKeys []string
+	}
+
+	last := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		last[entry.key] = i
+	}
+
+	keys := make([]string, 0, len(entries))
+	writes := make([]*blindWrite, 0, len(entries))
+	batch := db.internalIndex.NewBatch()
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if last[entry.key] != i {
+			continue
+		}
+
+		if isInternalKey([]byte(entry.key)) {
+			return ErrIdIsReserved
+		}
+
+		version, err := db.nextVersion(snapshot, entry.key)
+		if err != nil {
+			return err
+		}
+
+		// the key may have held a document type with secondary indexes
+		staleKeys, err := db.ownedSecondaryIndexKeys(snapshot, entry.key)
+		if err != nil {
+			return err
+		}
+
+		encoded, err := db.EncodeDocumentWithVersion(entry.doc, version)
+		if err != nil {
+			return err
+		}
+
+		created := false
+		if _, err := snapshot.Get([]byte(entry.key)); err == badger.ErrKeyNotFound {
+			created = true
+		} else if err != nil {
+			return err
+		}
+
+		if err := batch.Index(entry.key, indexValue(entry.doc)); err != nil {
+			return err
+		}
+
+		keys = append(keys, entry.key)
+		writes = append(writes, &blindWrite{
+			entry:     entry,
+			encoded:   encoded,
+			version:   version,
+			created:   created,
+			staleKeys: staleKeys,
+		})
+	}
+
+	writeBatch := db.internalDb.NewWriteBatch()
+	flushed := false
+	defer func() {
+		if !flushed {
+			writeBatch.Cancel()
+		}
+	}()
+
+	// the intent is written first so it is committed
+	// before any document of the chunk
+	intentKey, err := db.writeIndexIntent(writeBatch, keys)
+	if err != nil {
+		return err
+	}
+
+	for _, write := range writes {
+		if write.staleKeys != nil {
+			for _, key := range write.staleKeys {
+				if err := writeBatch.Delete([]byte(key)); err != nil {
+					return err
+				}
+			}
+			if err := writeBatch.Delete(indexManifestKey(write.entry.key)); err != nil {
+				return err
+			}
+		}
+
+		if err := setLastVersion(writeBatch, write.entry.key, write.version); err != nil {
+			return err
+		}
+
+		if err := db.setDocumentEntry(writeBatch, write.entry.key, write.encoded, write.entry.doc, write.created); err != nil {
+			return err
+		}
+	}
+
+	flushed = true
+	if err := writeBatch.Flush(); err != nil {
+		return ErrDatabaseTransactionFailed
+	}
+
+	// the intent stays in the database on failure and
+	// will be replayed by ReplayIntentLog
+	if err := db.internalIndex.Batch(batch); err != nil {
+		return ErrIndexStoreTransactionFailed
+	}
+
+	db.clearIndexIntent(intentKey)
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package dodod
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDatabase_BulkWrite(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.BulkWrite([]interface{}{&MyTestDocument{Id: "1"}}, nil); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	data := make([]interface{}, 0)
+	for i := 0; i < 2500; i++ {
+		data = append(data, &MyTestDocument{Id: fmt.Sprintf("%04d", i), Name: "bulk"})
+	}
+
+	progress := 0
+	result, err := db.BulkWrite(data, &BulkOptions{ChunkSize: 1000, Progress: func(chunk *BulkChunk) {
+		progress = progress + 1
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Written != 2500 || len(result.Chunks) != 3 || progress != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Chunks[1].Start != 1000 || result.Chunks[1].End != 2000 || result.Chunks[2].End != 2500 {
+		t.Fatalf("unexpected chunks: %v %v", result.Chunks[1], result.Chunks[2])
+	}
+	if result.Resume() != 2500 {
+		t.Fatalf("unexpected resume position: %d", result.Resume())
+	}
+
+	if total, _ := searchTotal(t, db, "bulk"); total != 2500 {
+		t.Fatalf("documents should be indexed, found %d", total)
+	}
+
+	// rewriting replaces the documents and increases their versions
+	result, err = db.BulkWrite(data[:10], &BulkOptions{ChunkBytes: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Chunks) != 10 {
+		t.Fatalf("every document should have a chunk of its own, found %d", len(result.Chunks))
+	}
+	if version, _ := db.GetVersion("0005"); version != 2 {
+		t.Fatalf("unexpected version: %d", version)
+	}
+
+	// the last document of a key written twice in a chunk is kept
+	result, err = db.BulkWrite([]interface{}{
+		&MyTestDocument{Id: "twice", Name: "first"},
+		&MyTestDocument{Id: "twice", Name: "second"},
+	}, nil)
+	if err != nil || result.Written != 2 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version, _ := db.GetVersion("twice"); version != 1 {
+		t.Fatalf("unexpected version: %d", version)
+	}
+	if _, docs, _ := db.Read([]string{"twice"}); docs[0].(*MyTestDocument).Name != "second" {
+		t.Fatalf("the last document should be kept")
+	}
+
+	result, err = db.BulkWrite([]interface{}{&MyTestDocument{Id: "a"}, &MyTestDocument{}}, nil)
+	if err != ErrIdCanNotBeEmpty || result.Written != 0 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.IsDocumentExists("a") {
+		t.Fatalf("documents of an unwritten chunk should not exist")
+	}
+
+	// the chunk is checked before anything is written to the WriteBatch
+	result, err = db.BulkWrite([]interface{}{&MyTestDocument{Id: "b"}, &MyTestDocument{Id: "\x00dodod/b"}}, nil)
+	if err != ErrIdIsReserved || result.Written != 0 || result.Resume() != 0 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.IsDocumentExists("b") {
+		t.Fatalf("documents of a failed chunk should not exist")
+	}
+}
+
+func TestDatabase_BulkWriteSecondaryIndex(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&IndexedTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	data := []interface{}{
+		&IndexedTestDocument{Id: "1", Email: "a@example.com", City: "Dhaka"},
+		&IndexedTestDocument{Id: "2", Email: "b@example.com", City: "Dhaka"},
+		&IndexedTestDocument{Id: "3", Email: "a@example.com", City: "Paris"},
+		&IndexedTestDocument{Id: "4", Email: "d@example.com", City: "Paris"},
+	}
+
+	result, err := db.BulkWrite(data, &BulkOptions{ChunkSize: 2})
+	if !errors.Is(err, ErrUniqueConstraintViolation) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Written != 2 || len(result.Chunks) != 2 || result.Chunks[1].Err == nil {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Resume() != 2 {
+		t.Fatalf("unexpected resume position: %d", result.Resume())
+	}
+
+	if total, _, _ := db.FindBy("city", "Dhaka"); total != 2 {
+		t.Fatalf("written chunk should maintain secondary indexes, found %d", total)
+	}
+	if db.IsDocumentExists("4") {
+		t.Fatalf("failed chunk should not write any document")
+	}
+}
@@ -0,0 +1,283 @@
+package dodod
+
+import (
+	"context"
+	"fmt"
+	"github.com/dgraph-io/badger/v2"
+)
+
+// DefaultByQueryBatchSize is the number of matching documents
+// changed per batch by DeleteByQuery and UpdateByQuery
+const DefaultByQueryBatchSize = 500
+
+// ByQueryOptions controls the batches of DeleteByQuery and UpdateByQuery
+type ByQueryOptions struct {
+	// BatchSize is the number of documents fetched per search page and
+	// written per database transaction and index batch,
+	// zero means DefaultByQueryBatchSize
+	BatchSize int
+}
+
+// ByQueryFailure is a matching document which could not be changed
+type ByQueryFailure struct {
+	Key string
+	Err error
+}
+
+func (f *ByQueryFailure) Error() string {
+	return fmt.Sprintf("dodod: `%s` failed: %v", f.Key, f.Err)
+}
+
+func (f *ByQueryFailure) Unwrap() error {
+	return f.Err
+}
+
+// ByQueryResult reports the outcome of DeleteByQuery and UpdateByQuery
+type ByQueryResult struct {
+	// Matched is the number of documents matched by the query
+	Matched uint64
+
+	// Affected is the number of documents deleted or updated
+	Affected uint64
+
+	// Failures are the matching documents which were left unchanged
+	Failures []*ByQueryFailure
+}
+
+// DeleteByQuery deletes every document matching the query from the database
+// and the index store. The query uses the Search query DSL, a nil query
+// matches all documents
+func (db *Database) DeleteByQuery(q map[string]interface{}, opts *ByQueryOptions) (*ByQueryResult, error) {
+	return db.DeleteByQueryContext(context.Background(), q, opts)
+}
+
+// DeleteByQueryContext is the context aware variant of DeleteByQuery,
+// batches written before the context is done are kept
+func (db *Database) DeleteByQueryContext(ctx context.Context, q map[string]interface{}, opts *ByQueryOptions) (*ByQueryResult, error) {
+	return db.byQuery(ctx, q, opts, func(txn *badger.Txn, key string, _ []byte) (interface{}, func() error, error) {
+		if isInternalKey([]byte(key)) {
+			return nil, nil, ErrIdIsReserved
+		}
+
+		return nil, func() error { return db.removeDocument(txn, key) }, nil
+	})
+}
+
+// UpdateByQuery calls the function with every document matching the query
+// and stores the changed document. The function changes the document in
+// place, an error returned by it leaves the document unchanged and is
+// reported as a failure. The query uses the Search query DSL, a nil query
+// matches all documents
+func (db *Database) UpdateByQuery(q map[string]interface{}, fn func(doc interface{}) error, opts *ByQueryOptions) (*ByQueryResult, error) {
+	return db.UpdateByQueryContext(context.Background(), q, fn, opts)
+}
+
+// UpdateByQueryContext is the context aware variant of UpdateByQuery,
+// batches written before the context is done are kept
+func (db *Database) UpdateByQueryContext(ctx context.Context,
+	q map[string]interface{},
+	fn func(doc interface{}) error,
+	opts *ByQueryOptions) (*ByQueryResult, error) {
+
+	if fn == nil {
+		return nil, ErrInvalidUpdateFunction
+	}
+
+	return db.byQuery(ctx, q, opts, func(txn *badger.Txn, key string, value []byte) (interface{}, func() error, error) {
+		if value == nil {
+			return nil, nil, ErrDocumentNotFound
+		}
+
+		doc, err := db.DecodeDocument(value)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := fn(doc); err != nil {
+			return nil, nil, err
+		}
+
+		document, ok := doc.(Document)
+		if !ok {
+			return nil, nil, ErrInvalidDocument
+		}
+		if db.documentKey(document) != key {
+			return nil, nil, ErrIdCanNotBeChanged
+		}
+
+		version, err := db.nextVersion(txn, key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		write, err := db.prepareDocumentWrite(txn, key, doc, version)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return doc, func() error { return db.writeDocument(txn, write) }, nil
+	})
+}
+
+// byQueryChange checks the change of a matching document without writing
+// anything and returns the document to index, nil to delete it from the
+// index store, along with the function writing the change. The value is
+// nil if the matching document is missing from the database
+type byQueryChange func(txn *badger.Txn, key string, value []byte) (interface{}, func() error, error)
+
+// byQuery pages through the documents matching the query ordered by id and
+// applies the change to every page inside one transaction
+func (db *Database) byQuery(ctx context.Context,
+	q map[string]interface{},
+	opts *ByQueryOptions,
+	change byQueryChange) (*ByQueryResult, error) {
+
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	if db.isReadOnly {
+		return nil, ErrDatabaseIsReadOnly
+	}
+
+	if opts == nil {
+		opts = &ByQueryOptions{}
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultByQueryBatchSize
+	}
+
+	result := &ByQueryResult{Failures: make([]*ByQueryFailure, 0)}
+
+	var lastKey string
+	for {
+		builder := NewSearchRequestBuilder().Size(batchSize).Sort("_id")
+		if q != nil {
+			builder.QueryMap(q)
+		}
+		if lastKey != "" {
+			builder.SearchAfter(lastKey)
+		}
+
+		request, err := builder.Build()
+		if err != nil {
+			return nil, err
+		}
+
+		searchRequest, err := request.ToBleveSearchRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		db.indexLock.RLock()
+		searchResult, err := db.internalIndex.SearchInContext(ctx, searchRequest)
+		db.indexLock.RUnlock()
+		if err != nil {
+			return result, err
+		}
+
+		if len(searchResult.Hits) == 0 {
+			return result, nil
+		}
+
+		keys := make([]string, 0, len(searchResult.Hits))
+		for _, hit := range searchResult.Hits {
+			keys = append(keys, hit.ID)
+		}
+		lastKey = keys[len(keys)-1]
+		result.Matched = result.Matched + uint64(len(keys))
+
+		if err := db.applyByQueryBatch(ctx, keys, change, result); err != nil {
+			return result, err
+		}
+
+		if len(searchResult.Hits) < batchSize {
+			return result, nil
+		}
+	}
+}
+
+// applyByQueryBatch applies the change to the keys inside one transaction
+// and one index batch. A key failing its check is reported as a failure and
+// left untouched, while a failed write discards the whole batch since the
+// transaction may already hold part of the change. A failed commit fails
+// every key of the batch
+func (db *Database) applyByQueryBatch(ctx context.Context,
+	keys []string,
+	change byQueryChange,
+	result *ByQueryResult) error {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
+	internalBatchTxn := db.internalDb.NewTransaction(true)
+	defer internalBatchTxn.Discard()
+
+	batch := db.internalIndex.NewBatch()
+	changed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var value []byte
+		item, err := internalBatchTxn.Get([]byte(key))
+		if err == nil {
+			if value, err = item.ValueCopy(nil); err != nil {
+				return err
+			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+
+		doc, write, err := change(internalBatchTxn, key, value)
+		if err != nil {
+			result.Failures = append(result.Failures, &ByQueryFailure{Key: key, Err: err})
+			continue
+		}
+
+		if err := write(); err != nil {
+			return err
+		}
+
+		if doc == nil {
+			batch.Delete(key)
+		} else if err := batch.Index(key, indexValue(doc)); err != nil {
+			return err
+		}
+		changed = append(changed, key)
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	intentKey, err := db.writeIndexIntent(internalBatchTxn, changed)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := internalBatchTxn.Commit(); err != nil {
+		for _, key := range changed {
+			result.Failures = append(result.Failures, &ByQueryFailure{Key: key, Err: commitError(err)})
+		}
+		return nil
+	}
+
+	result.Affected = result.Affected + uint64(len(changed))
+
+	// the intent stays in the database on failure and
+	// will be replayed by ReplayIntentLog
+	if err := db.internalIndex.Batch(batch); err != nil {
+		return ErrIndexStoreTransactionFailed
+	}
+
+	db.clearIndexIntent(intentKey)
+
+	return nil
+}
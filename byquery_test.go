@@ -0,0 +1,204 @@
+package dodod
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func matchQuery(term string) map[string]interface{} {
+	return map[string]interface{}{"name": "Match", "p": map[string]interface{}{"match": term}}
+}
+
+func TestDatabase_DeleteByQuery(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.DeleteByQuery(nil, nil); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	data := make([]interface{}, 0)
+	for i := 0; i < 10; i++ {
+		data = append(data, &MyTestDocument{Id: fmt.Sprintf("old%d", i), Name: "stale"})
+	}
+	for i := 0; i < 5; i++ {
+		data = append(data, &MyTestDocument{Id: fmt.Sprintf("new%d", i), Name: "fresh"})
+	}
+	if err := db.Create(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := db.DeleteByQuery(matchQuery("stale"), &ByQueryOptions{BatchSize: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Matched != 10 || result.Affected != 10 || len(result.Failures) != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	if db.IsDocumentExists("old3") || db.IsIndexExists("old3") {
+		t.Fatalf("matching documents should be deleted")
+	}
+	if total, _ := searchTotal(t, db, "fresh"); total != 5 {
+		t.Fatalf("other documents should be kept, found %d", total)
+	}
+
+	if _, err := db.DeleteByQuery(map[string]interface{}{"name": "Unknown"}, nil); err == nil {
+		t.Fatalf("invalid query should fail")
+	}
+
+	// a nil query matches all documents
+	result, err = db.DeleteByQuery(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Affected != 5 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestDatabase_UpdateByQuery(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	data := make([]interface{}, 0)
+	for i := 0; i < 12; i++ {
+		data = append(data, &MyTestDocument{Id: fmt.Sprintf("%02d", i), Name: "draft"})
+	}
+	if err := db.Create(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.UpdateByQuery(nil, nil, nil); err != ErrInvalidUpdateFunction {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	failure := errors.New("skip")
+	result, err := db.UpdateByQuery(matchQuery("draft"), func(doc interface{}) error {
+		d := doc.(*MyTestDocument)
+		switch d.Id {
+		case "03":
+			return failure
+		case "07":
+			d.Id = "changed"
+			return nil
+		}
+		d.Name = "published"
+		return nil
+	}, &ByQueryOptions{BatchSize: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Matched != 12 || result.Affected != 10 || len(result.Failures) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Failures[0].Key != "03" || !errors.Is(result.Failures[0], failure) {
+		t.Fatalf("unexpected failure: %v", result.Failures[0])
+	}
+	if result.Failures[1].Key != "07" || !errors.Is(result.Failures[1], ErrIdCanNotBeChanged) {
+		t.Fatalf("unexpected failure: %v", result.Failures[1])
+	}
+
+	if total, _ := searchTotal(t, db, "published"); total != 10 {
+		t.Fatalf("updated documents should be indexed, found %d", total)
+	}
+
+	_, docs, _ := db.Read([]string{"03", "05"})
+	if docs[0].(*MyTestDocument).Name != "draft" || docs[1].(*MyTestDocument).Name != "published" {
+		t.Fatalf("unexpected documents: %v %v", docs[0], docs[1])
+	}
+
+	if version, _ := db.GetVersion("05"); version != 2 {
+		t.Fatalf("update should increase the version, found %d", version)
+	}
+}
+
+func TestDatabase_UpdateByQueryFailureKeepsIndexes(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&IndexedTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.Create([]interface{}{
+		&IndexedTestDocument{Id: "1", Email: "one@example.com", City: "dhaka"},
+		&IndexedTestDocument{Id: "2", Email: "two@example.com", City: "dhaka"},
+		&IndexedTestDocument{Id: "3", Email: "three@example.com", City: "dhaka"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the document 2 takes the unique value of 1 and fails
+	result, err := db.UpdateByQuery(nil, func(doc interface{}) error {
+		d := doc.(*IndexedTestDocument)
+		d.City = "rome"
+		if d.Id == "2" {
+			d.Email = "one@example.com"
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Affected != 2 || len(result.Failures) != 1 || result.Failures[0].Key != "2" ||
+		!errors.Is(result.Failures[0], ErrUniqueConstraintViolation) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	if total, docs, err := db.FindBy("email", "two@example.com"); err != nil || total != 1 ||
+		docs[0].(*IndexedTestDocument).Id != "2" {
+		t.Fatalf("failed document should keep its unique value, found: %d, error: %v", total, err)
+	}
+	if total, docs, err := db.FindBy("city", "dhaka"); err != nil || total != 1 ||
+		docs[0].(*IndexedTestDocument).Id != "2" {
+		t.Fatalf("failed document should keep its index entries, found: %d, error: %v", total, err)
+	}
+	if total, _, err := db.FindBy("city", "rome"); err != nil || total != 2 {
+		t.Fatalf("updated documents should be indexed, found: %d, error: %v", total, err)
+	}
+}
@@ -0,0 +1,352 @@
+package dodod
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/dgraph-io/badger/v2"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ChangeOp is the kind of a document change
+type ChangeOp string
+
+const (
+	ChangeOpCreate ChangeOp = "create"
+	ChangeOpUpdate ChangeOp = "update"
+	ChangeOpDelete ChangeOp = "delete"
+)
+
+// feedMarkerPrefix holds the marker written by Subscribe to find
+// the first change delivered by the database subscription
+var feedMarkerPrefix = []byte("\x00dodod/feed/")
+
+// feedMarkerInterval is the interval between marker writes
+// until the subscription receives one
+const feedMarkerInterval = 10 * time.Millisecond
+
+var feedMarkerCounter uint64
+
+// createdMeta is the badger user meta of a document entry written
+// under a key which held no document
+const createdMeta byte = 1
+
+// ChangeEvent describes a document change delivered by Subscribe
+type ChangeEvent struct {
+	Op ChangeOp
+
+	// DocumentType is empty for a deletion in the id key layout
+	// since the deleted document is no longer known
+	DocumentType string
+
+	Id  string
+	Key string
+
+	// Version is the version of the new document, zero for a deletion
+	Version uint64
+
+	// Document is the decoded new document, nil for a deletion
+	// or a document type which is not registered
+	Document interface{}
+
+	// Data is the JSON encoded new document, nil for a deletion
+	Data []byte
+
+	// Position is the commit position of the change, changes written in
+	// the same transaction share their position
+	Position uint64
+}
+
+// SubscribeFilter selects the changes delivered by Subscribe
+type SubscribeFilter struct {
+	// DocumentTypes limits the changes to the document types,
+	// empty means every document type
+	DocumentTypes []string
+
+	// Since resumes the feed after the position, the changes written after
+	// it are delivered first ordered by their position. Only the latest
+	// change of every document is delivered and deletions are missing once
+	// the database compacted them. Zero delivers only new changes
+	Since uint64
+}
+
+// Subscribe delivers the document changes matching the filter to the
+// function until the database is closed or the function returns an error.
+//
+// Consumers persist the Position of the last handled change and pass it as
+// Since after a restart, since changes of one transaction share their
+// position the position should be persisted once all of them were handled.
+// Documents dropped by their expiry produce no change
+func (db *Database) Subscribe(filter *SubscribeFilter, fn func(event *ChangeEvent) error) error {
+	return db.SubscribeContext(context.Background(), filter, fn)
+}
+
+// SubscribeContext is the context aware variant of Subscribe,
+// it returns once the context is done
+func (db *Database) SubscribeContext(ctx context.Context, filter *SubscribeFilter, fn func(event *ChangeEvent) error) error {
+	if !db.IsDatabaseReady() {
+		return ErrDatabaseIsNotOpen
+	}
+
+	if db.isReadOnly {
+		return ErrDatabaseIsReadOnly
+	}
+
+	if fn == nil {
+		return ErrInvalidCallback
+	}
+
+	if filter == nil {
+		filter = &SubscribeFilter{}
+	}
+
+	types := make(map[string]bool)
+	for _, t := range filter.DocumentTypes {
+		types[t] = true
+	}
+
+	marker := make([]byte, len(feedMarkerPrefix)+16)
+	copy(marker, feedMarkerPrefix)
+	binary.BigEndian.PutUint64(marker[len(feedMarkerPrefix):], uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint64(marker[len(feedMarkerPrefix)+8:], atomic.AddUint64(&feedMarkerCounter, 1))
+
+	prefixes := [][]byte{marker}
+	if db.keyLayout == KeyLayoutType && len(types) > 0 {
+		for t := range types {
+			prefixes = append(prefixes, []byte(TypeNamespacedKey(t, "")))
+		}
+	} else {
+		prefixes = append(prefixes, prefixesExcluding(internalKeyPrefix)...)
+	}
+
+	subscribeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// changes published before the marker are covered by the catch up,
+	// changes up to the marker or the snapshot of the catch up are skipped
+	var live int32
+	var caughtUp uint64
+
+	deliver := func(key []byte, value []byte, meta byte, position uint64) error {
+		if event := db.changeEvent(key, value, meta, position, types); event != nil {
+			return fn(event)
+		}
+		return nil
+	}
+
+	go db.writeFeedMarker(subscribeCtx, marker, &live)
+
+	return db.internalDb.Subscribe(subscribeCtx, func(list *badger.KVList) error {
+		for _, kv := range list.Kv {
+			if atomic.LoadInt32(&live) == 0 {
+				if string(kv.Key) != string(marker) {
+					continue
+				}
+
+				atomic.StoreInt32(&live, 1)
+
+				// every change after the marker is delivered by the subscription
+				caughtUp = kv.Version
+				if filter.Since > 0 {
+					readTs, err := db.catchUpFeed(ctx, filter.Since, deliver)
+					if err != nil {
+						return err
+					}
+					caughtUp = readTs
+				}
+				continue
+			}
+
+			if kv.Version <= caughtUp {
+				continue
+			}
+
+			var meta byte
+			if len(kv.Meta) > 0 {
+				meta = kv.Meta[0]
+			}
+
+			if err := deliver(kv.Key, kv.Value, meta, kv.Version); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, prefixes...)
+}
+
+// prefixesExcluding returns the prefixes matching every key which does not
+// start with the excluded prefix, except the keys which are the leading part
+// of it. The database subscription does not match an empty prefix and looks
+// the prefixes up in a trie so their number does not slow down writes
+func prefixesExcluding(excluded []byte) [][]byte {
+	prefixes := make([][]byte, 0, len(excluded)*0xFF)
+	for i := range excluded {
+		for b := 0; b <= 0xFF; b++ {
+			if byte(b) == excluded[i] {
+				continue
+			}
+			prefix := make([]byte, i+1)
+			copy(prefix, excluded[:i])
+			prefix[i] = byte(b)
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// writeFeedMarker writes the marker until the subscription received it
+// and removes it afterwards
+func (db *Database) writeFeedMarker(ctx context.Context, marker []byte, live *int32) {
+	ticker := time.NewTicker(feedMarkerInterval)
+	defer ticker.Stop()
+
+	for atomic.LoadInt32(live) == 0 {
+		err := db.internalDb.Update(func(txn *badger.Txn) error {
+			return txn.Set(marker, []byte{})
+		})
+		if err != nil {
+			DefaultLogger.Warningf("failed to write change feed marker: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+
+	_ = db.internalDb.Update(func(txn *badger.Txn) error {
+		return txn.Delete(marker)
+	})
+}
+
+// catchUpFeed delivers the latest change of every document changed after
+// the position ordered by position, it returns the position of the snapshot.
+// A document dropped by its expiry is skipped the same way as by the
+// subscription
+func (db *Database) catchUpFeed(ctx context.Context,
+	since uint64,
+	deliver func(key []byte, value []byte, meta byte, position uint64) error) (uint64, error) {
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	type change struct {
+		key      []byte
+		value    []byte
+		meta     byte
+		position uint64
+	}
+
+	opts := badger.DefaultIteratorOptions
+	opts.AllVersions = true
+	it := txn.NewIterator(opts)
+
+	now := uint64(time.Now().Unix())
+	changes := make([]*change, 0)
+	var lastKey []byte
+	for it.Rewind(); it.Valid(); it.Next() {
+		if err := ctx.Err(); err != nil {
+			it.Close()
+			return 0, err
+		}
+
+		item := it.Item()
+		if isInternalKey(item.Key()) {
+			continue
+		}
+
+		// the first version of a key is the latest one
+		if lastKey != nil && string(lastKey) == string(item.Key()) {
+			continue
+		}
+		lastKey = item.KeyCopy(nil)
+
+		if item.Version() <= since {
+			continue
+		}
+
+		if item.ExpiresAt() > 0 && item.ExpiresAt() <= now {
+			continue
+		}
+
+		c := &change{key: lastKey, meta: item.UserMeta(), position: item.Version()}
+		if !item.IsDeletedOrExpired() {
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				it.Close()
+				return 0, err
+			}
+			c.value = value
+		}
+		changes = append(changes, c)
+	}
+	it.Close()
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].position < changes[j].position
+	})
+
+	for _, c := range changes {
+		if err := deliver(c.key, c.value, c.meta, c.position); err != nil {
+			return 0, err
+		}
+	}
+
+	return txn.ReadTs(), nil
+}
+
+// changeEvent builds the event of a change, nil if the change is not
+// a document change or its document type is filtered out
+func (db *Database) changeEvent(key []byte, value []byte, meta byte, position uint64, types map[string]bool) *ChangeEvent {
+	if isInternalKey(key) {
+		return nil
+	}
+
+	event := &ChangeEvent{Key: string(key), Id: string(key), Position: position}
+
+	if len(value) == 0 {
+		event.Op = ChangeOpDelete
+		if db.keyLayout == KeyLayoutType {
+			if docType, id, ok := splitTypeNamespacedKey(event.Key); ok {
+				event.DocumentType = docType
+				event.Id = id
+			}
+		}
+
+		if len(types) > 0 && event.DocumentType != "" && !types[event.DocumentType] {
+			return nil
+		}
+		return event
+	}
+
+	docType, err := documentTypeOf(value)
+	if err != nil {
+		return nil
+	}
+
+	if len(types) > 0 && !types[docType] {
+		return nil
+	}
+
+	event.DocumentType = docType
+	if db.keyLayout == KeyLayoutType {
+		event.Id = db.rawId(event.Key, docType)
+	}
+
+	// entries written before the created meta have version one on creation
+	event.Data, event.Version = envelopeData(value, uint32(len(docType)))
+	if meta == createdMeta || event.Version == 1 {
+		event.Op = ChangeOpCreate
+	} else {
+		event.Op = ChangeOpUpdate
+	}
+
+	if doc, err := db.DecodeDocument(value); err == nil {
+		event.Document = doc
+	}
+
+	return event
+}
@@ -0,0 +1,235 @@
+package dodod
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// subscribeEvents runs Subscribe in the background and waits until
+// the subscription is live
+func subscribeEvents(t *testing.T, db *Database, filter *SubscribeFilter) (chan *ChangeEvent, chan error, context.CancelFunc) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan *ChangeEvent, 100)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- db.SubscribeContext(ctx, filter, func(event *ChangeEvent) error {
+			events <- event
+			return nil
+		})
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+
+	return events, done, cancel
+}
+
+func nextEvent(t *testing.T, events chan *ChangeEvent) *ChangeEvent {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no change event delivered")
+	}
+
+	return nil
+}
+
+func TestDatabase_Subscribe(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.RegisterDocument(&CustomDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Subscribe(nil, func(event *ChangeEvent) error { return nil }); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.Subscribe(nil, nil); err != ErrInvalidCallback {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, done, cancel := subscribeEvents(t, db, &SubscribeFilter{DocumentTypes: []string{"MyTestDocument"}})
+
+	if err := db.Create([]interface{}{&CustomDocument{Id: "c1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.Create([]interface{}{&MyTestDocument{Id: "1", Name: "first"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.Update([]interface{}{&MyTestDocument{Id: "1", Name: "changed"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.Delete([]interface{}{&MyTestDocument{Id: "1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	created := nextEvent(t, events)
+	if created.Op != ChangeOpCreate || created.Id != "1" || created.DocumentType != "MyTestDocument" || created.Version != 1 {
+		t.Fatalf("unexpected event: %+v", created)
+	}
+	if created.Document.(*MyTestDocument).Name != "first" || string(created.Data) != `{"id":"1","name":"first"}` {
+		t.Fatalf("unexpected document: %+v", created)
+	}
+
+	updated := nextEvent(t, events)
+	if updated.Op != ChangeOpUpdate || updated.Version != 2 || updated.Position <= created.Position {
+		t.Fatalf("unexpected event: %+v", updated)
+	}
+
+	deleted := nextEvent(t, events)
+	if deleted.Op != ChangeOpDelete || deleted.Id != "1" || deleted.Document != nil {
+		t.Fatalf("unexpected event: %+v", deleted)
+	}
+
+	cancel()
+	if err := <-done; err != nil && err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	failure := errors.New("stop")
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = db.Create([]interface{}{&MyTestDocument{Id: "2"}})
+	}()
+	if err := db.Subscribe(nil, func(event *ChangeEvent) error { return failure }); err != failure {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDatabase_SubscribeSince(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+	db.SetTypeNamespacedKeys(true)
+
+	// expiring documents are written an hour ago
+	db.clock = func() time.Time {
+		return time.Now().Add(-time.Hour)
+	}
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.RegisterDocument(&SessionTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	events, done, cancel := subscribeEvents(t, db, nil)
+
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&MyTestDocument{Id: "2", Name: "second"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// changes of one transaction share their position in any order
+	first := nextEvent(t, events)
+	second := nextEvent(t, events)
+	if first.Key != "MyTestDocument:"+first.Id || first.Id == second.Id || second.Position != first.Position {
+		t.Fatalf("unexpected events: %+v %+v", first, second)
+	}
+
+	cancel()
+	<-done
+
+	// changes written while no consumer is subscribed, the expired
+	// document produces no change
+	if err := db.Create([]interface{}{
+		&SessionTestDocument{Id: "expired", Expires: time.Now().Add(-time.Hour + time.Second)},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.Update([]interface{}{&MyTestDocument{Id: "2", Name: "changed"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.Delete([]interface{}{&MyTestDocument{Id: "1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, done, cancel = subscribeEvents(t, db, &SubscribeFilter{Since: second.Position})
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	updated := nextEvent(t, events)
+	if updated.Op != ChangeOpUpdate || updated.Id != "2" || updated.Document.(*MyTestDocument).Name != "changed" {
+		t.Fatalf("unexpected event: %+v", updated)
+	}
+
+	deleted := nextEvent(t, events)
+	if deleted.Op != ChangeOpDelete || deleted.DocumentType != "MyTestDocument" || deleted.Id != "1" {
+		t.Fatalf("unexpected event: %+v", deleted)
+	}
+
+	if err := db.Create([]interface{}{&MyTestDocument{Id: "3"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	created := nextEvent(t, events)
+	if created.Op != ChangeOpCreate || created.Id != "3" {
+		t.Fatalf("unexpected event: %+v", created)
+	}
+}
+
+func TestPrefixesExcluding(t *testing.T) {
+	t.Helper()
+
+	prefixes := prefixesExcluding(internalKeyPrefix)
+
+	matches := func(key string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, string(prefix)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, key := range []string{"1", "MyTestDocument:1", "\x00a", "\x00dodo/", "\x00dodox/1", "\xff"} {
+		if !matches(key) {
+			t.Fatalf("key %q should be matched", key)
+		}
+	}
+
+	for _, key := range []string{"\x00dodod/", "\x00dodod/intent/1", "\x00dodod/version/1"} {
+		if matches(key) {
+			t.Fatalf("key %q should not be matched", key)
+		}
+	}
+}
@@ -0,0 +1,558 @@
+// Command dodod inspects and administers dodod databases.
+//
+// Usage:
+//
+//	dodod -path <database path> [-verbose] <command> [arguments]
+//
+// The commands are:
+//
+//	info                             show the dodod.json metadata
+//	count [-type T]                  count the documents
+//	get [-type T] <id>               print the json data of a document
+//	put -type T <id> [file]          store the json data of a document
+//	delete [-type T] <id>            delete a document
+//	search [-data] <file>            run the search input map read from the file
+//	export [-type T,...] [file]      export the documents as JSON Lines
+//	import [-skip-invalid] [file]    import the documents from JSON Lines
+//	passwd                           change the password of a protected database
+//	compact                          reclaim the disk space of the database
+//
+// Without the type the id is used as the document key. A missing file or
+// `-` reads standard input or writes standard output.
+//
+// A database is password protected when it is created, passwd can not set
+// the first password of a database created without one.
+//
+// The password of a protected database is read from the DODOD_PASSWORD
+// environment variable, prompted on a terminal or read as the first line
+// of standard input otherwise.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mkawserm/dodod"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// passwordEnv is the environment variable holding the database password
+const passwordEnv = "DODOD_PASSWORD"
+
+var errUsage = errors.New("invalid usage")
+
+var errNotPasswordProtected = errors.New("the database is not password protected, " +
+	"a password can only be set when the database is created")
+
+// quietLogger drops the informational logs of the database
+type quietLogger struct {
+	*log.Logger
+}
+
+func (l *quietLogger) Errorf(f string, v ...interface{}) {
+	l.Printf("ERROR: "+f, v...)
+}
+
+func (l *quietLogger) Warningf(f string, v ...interface{}) {
+	l.Printf("WARNING: "+f, v...)
+}
+
+func (l *quietLogger) Infof(f string, v ...interface{}) {}
+
+func (l *quietLogger) Debugf(f string, v ...interface{}) {}
+
+// cli runs a single command against the database at the path
+type cli struct {
+	stdin  *bufio.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	path string
+
+	// readPassword prompts for a password
+	readPassword func(prompt string) (string, error)
+}
+
+func main() {
+	c := &cli{
+		stdin:  bufio.NewReader(os.Stdin),
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+	c.readPassword = c.promptPassword
+
+	os.Exit(c.run(os.Args[1:]))
+}
+
+// run executes the command line and returns the exit code
+func (c *cli) run(args []string) int {
+	flags := flag.NewFlagSet("dodod", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.StringVar(&c.path, "path", "", "path of the database")
+	verbose := flags.Bool("verbose", false, "show the informational logs of the database")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(c.stderr, "usage: dodod -path <database path> [-verbose] "+
+			"info|count|get|put|delete|search|export|import|passwd|compact [arguments]\n"+
+			"passwd changes the password of a protected database, it can not set a first password")
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if c.path == "" || flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	if !*verbose {
+		dodod.DefaultLogger = &quietLogger{Logger: log.New(c.stderr, "dodod ", log.LstdFlags)}
+	}
+
+	commands := map[string]func(args []string) error{
+		"info":    c.info,
+		"count":   c.count,
+		"get":     c.get,
+		"put":     c.put,
+		"delete":  c.delete,
+		"search":  c.search,
+		"export":  c.export,
+		"import":  c.importDocuments,
+		"passwd":  c.passwd,
+		"compact": c.compact,
+	}
+
+	command, ok := commands[flags.Arg(0)]
+	if !ok {
+		flags.Usage()
+		return 2
+	}
+
+	if err := command(flags.Args()[1:]); err == errUsage || err == flag.ErrHelp {
+		return 2
+	} else if err != nil {
+		_, _ = fmt.Fprintf(c.stderr, "dodod: %s: %v\n", flags.Arg(0), err)
+		return 1
+	}
+
+	return 0
+}
+
+// newFlags returns the flag set of a command
+func (c *cli) newFlags(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(c.stderr, "usage: dodod -path <database path> %s %s\n", name, usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parse parses the command arguments which must leave
+// between min and max positional arguments
+func parse(flags *flag.FlagSet, args []string, min int, max int) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() < min || flags.NArg() > max {
+		flags.Usage()
+		return errUsage
+	}
+
+	return nil
+}
+
+// readConfig reads the dodod.json of the database
+func (c *cli) readConfig() (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(filepath.Join(c.path, "dodod.json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no database found at %s", c.path)
+	} else if err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]interface{})
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, dodod.ErrJSONParseFailed
+	}
+
+	return config, nil
+}
+
+// password returns the password of the database, empty when it is not protected
+func (c *cli) password(config map[string]interface{}) (string, error) {
+	if protected, _ := config["isPasswordProtected"].(bool); !protected {
+		return "", nil
+	}
+
+	if password, ok := os.LookupEnv(passwordEnv); ok {
+		return password, nil
+	}
+
+	return c.readPassword("Password: ")
+}
+
+// promptPassword reads a password from the terminal without echoing it,
+// or the first line of standard input when it is not a terminal
+func (c *cli) promptPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if terminal.IsTerminal(fd) {
+		_, _ = fmt.Fprint(c.stderr, prompt)
+		password, err := terminal.ReadPassword(fd)
+		_, _ = fmt.Fprintln(c.stderr)
+		return string(password), err
+	}
+
+	line, err := c.stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// newDatabase returns the database at the path with its password,
+// the database is not opened
+func (c *cli) newDatabase() (*dodod.Database, error) {
+	config, err := c.readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := c.password(config)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &dodod.Database{}
+	db.SetupDefaults()
+	db.SetDbPath(c.path)
+	db.SetDbPassword(password)
+
+	return db, nil
+}
+
+// withDatabase opens the database for the duration of the function,
+// commands which only read open it read only
+func (c *cli) withDatabase(readOnly bool, fn func(db *dodod.Database) error) error {
+	db, err := c.newDatabase()
+	if err != nil {
+		return err
+	}
+	db.SetReadOnly(readOnly)
+
+	if err := db.Open(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	return fn(db)
+}
+
+// input opens the named file, standard input for an empty name or `-`
+func (c *cli) input(name string) (io.Reader, func(), error) {
+	if name == "" || name == "-" {
+		return c.stdin, func() {}, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return f, func() { _ = f.Close() }, nil
+}
+
+// output creates the named file, standard output for an empty name or `-`
+func (c *cli) output(name string) (io.Writer, func() error, error) {
+	if name == "" || name == "-" {
+		return c.stdout, func() error { return nil }, nil
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return f, f.Close, nil
+}
+
+// printJSON writes the value as indented json
+func (c *cli) printJSON(v interface{}) error {
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (c *cli) info(args []string) error {
+	if err := parse(c.newFlags("info", ""), args, 0, 0); err != nil {
+		return err
+	}
+
+	config, err := c.readConfig()
+	if err != nil {
+		return err
+	}
+
+	// the encoded key is a password hash and is not useful to show
+	delete(config, "encodedKey")
+	config["path"] = c.path
+
+	return c.printJSON(config)
+}
+
+func (c *cli) count(args []string) error {
+	flags := c.newFlags("count", "[-type T]")
+	docType := flags.String("type", "", "count the documents of the type only")
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	return c.withDatabase(true, func(db *dodod.Database) error {
+		total, err := db.Count(*docType)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(c.stdout, total)
+		return err
+	})
+}
+
+func (c *cli) get(args []string) error {
+	flags := c.newFlags("get", "[-type T] <id>")
+	docType := flags.String("type", "", "type of the document")
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	return c.withDatabase(true, func(db *dodod.Database) error {
+		key := flags.Arg(0)
+		if *docType != "" {
+			key = db.DocumentKey(*docType, flags.Arg(0))
+		}
+
+		raw, err := db.ReadRaw(key)
+		if err != nil {
+			return err
+		}
+
+		if *docType != "" && raw.Type != *docType {
+			return dodod.ErrDocumentNotFound
+		}
+
+		return c.printJSON(raw.Data)
+	})
+}
+
+func (c *cli) put(args []string) error {
+	flags := c.newFlags("put", "-type T <id> [file]")
+	docType := flags.String("type", "", "type of the document")
+	if err := parse(flags, args, 1, 2); err != nil {
+		return err
+	}
+
+	if *docType == "" {
+		flags.Usage()
+		return errUsage
+	}
+
+	return c.withDatabase(false, func(db *dodod.Database) error {
+		r, done, err := c.input(flags.Arg(1))
+		if err != nil {
+			return err
+		}
+		defer done()
+
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		return db.PutRaw(*docType, flags.Arg(0), data)
+	})
+}
+
+func (c *cli) delete(args []string) error {
+	flags := c.newFlags("delete", "[-type T] <id>")
+	docType := flags.String("type", "", "type of the document")
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	return c.withDatabase(false, func(db *dodod.Database) error {
+		result, err := db.DeleteByIds(*docType, []string{flags.Arg(0)})
+		if err != nil {
+			return err
+		}
+
+		if len(result.Missing) > 0 {
+			return dodod.ErrDocumentNotFound
+		}
+
+		return nil
+	})
+}
+
+func (c *cli) search(args []string) error {
+	flags := c.newFlags("search", "[-data] <file>")
+	includeData := flags.Bool("data", false, "include the json data of the hits")
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	return c.withDatabase(true, func(db *dodod.Database) error {
+		r, done, err := c.input(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer done()
+
+		input := make(map[string]interface{})
+		if err := json.NewDecoder(r).Decode(&input); err != nil {
+			return dodod.ErrJSONParseFailed
+		}
+
+		output, err := db.Search(input, "map")
+		if err != nil {
+			return err
+		}
+
+		if *includeData {
+			hits, _ := output.(map[string]interface{})["hits"].([]interface{})
+			for _, h := range hits {
+				hit, ok := h.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				id, _ := hit["id"].(string)
+				if raw, err := db.ReadRaw(id); err == nil {
+					hit["data"] = raw.Data
+				}
+			}
+		}
+
+		return c.printJSON(output)
+	})
+}
+
+func (c *cli) export(args []string) error {
+	flags := c.newFlags("export", "[-type T,...] [file]")
+	types := flags.String("type", "", "comma separated document types to export")
+	if err := parse(flags, args, 0, 1); err != nil {
+		return err
+	}
+
+	filter := &dodod.ExportFilter{}
+	if *types != "" {
+		filter.DocumentTypes = strings.Split(*types, ",")
+	}
+
+	return c.withDatabase(true, func(db *dodod.Database) error {
+		w, done, err := c.output(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		result, err := db.Export(w, filter)
+		if closeErr := done(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(c.stderr, "exported %d documents, skipped %d\n", result.Exported, result.Skipped)
+		return err
+	})
+}
+
+func (c *cli) importDocuments(args []string) error {
+	flags := c.newFlags("import", "[-skip-invalid] [file]")
+	skipInvalid := flags.Bool("skip-invalid", false, "report invalid lines and import the valid ones")
+	if err := parse(flags, args, 0, 1); err != nil {
+		return err
+	}
+
+	return c.withDatabase(false, func(db *dodod.Database) error {
+		r, done, err := c.input(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer done()
+
+		result, err := db.Import(r, &dodod.ImportOptions{SkipInvalid: *skipInvalid, AllowUnregistered: true})
+		if result != nil {
+			for _, lineError := range result.Errors {
+				_, _ = fmt.Fprintln(c.stderr, lineError)
+			}
+			_, _ = fmt.Fprintf(c.stderr, "imported %d documents\n", result.Imported)
+		}
+
+		return err
+	})
+}
+
+func (c *cli) passwd(args []string) error {
+	if err := parse(c.newFlags("passwd", ""), args, 0, 0); err != nil {
+		return err
+	}
+
+	config, err := c.readConfig()
+	if err != nil {
+		return err
+	}
+
+	if protected, _ := config["isPasswordProtected"].(bool); !protected {
+		return errNotPasswordProtected
+	}
+
+	db, err := c.newDatabase()
+	if err != nil {
+		return err
+	}
+
+	password, err := c.readPassword("New password: ")
+	if err != nil {
+		return err
+	}
+
+	confirmation, err := c.readPassword("Retype new password: ")
+	if err != nil {
+		return err
+	}
+
+	if password == "" {
+		return dodod.ErrEmptyPassword
+	}
+
+	if password != confirmation {
+		return errors.New("passwords do not match")
+	}
+
+	return db.ChangePassword(password)
+}
+
+func (c *cli) compact(args []string) error {
+	if err := parse(c.newFlags("compact", ""), args, 0, 0); err != nil {
+		return err
+	}
+
+	return c.withDatabase(false, func(db *dodod.Database) error {
+		return db.Compact()
+	})
+}
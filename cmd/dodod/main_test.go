@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/mkawserm/dodod"
+)
+
+func newTestDatabase(t *testing.T, path string, password string) {
+	t.Helper()
+
+	db := &dodod.Database{}
+	db.SetupDefaults()
+	db.SetDbPath(path)
+	db.SetDbPassword(password)
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = db.Close()
+}
+
+// runCli runs the command line with the input and the passwords answered to the prompts
+func runCli(t *testing.T, input string, passwords []string, args ...string) (int, string, string) {
+	t.Helper()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	c := &cli{stdin: bufio.NewReader(strings.NewReader(input)), stdout: stdout, stderr: stderr}
+	c.readPassword = func(prompt string) (string, error) {
+		if len(passwords) == 0 {
+			t.Fatalf("unexpected password prompt: %s", prompt)
+		}
+		password := passwords[0]
+		passwords = passwords[1:]
+		return password, nil
+	}
+
+	code := c.run(args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCli(t *testing.T) {
+	dbPath := "/tmp/dodod-cli"
+	defer func() {
+		_ = os.RemoveAll(dbPath)
+		debug.FreeOSMemory()
+	}()
+
+	if code, _, _ := runCli(t, "", nil, "-path", dbPath, "info"); code != 1 {
+		t.Fatalf("missing database should fail, exit code %d", code)
+	}
+
+	newTestDatabase(t, dbPath, "secret")
+
+	if code, _, _ := runCli(t, "", nil, "-path", dbPath, "unknown"); code != 2 {
+		t.Fatalf("unknown command should fail with usage, exit code %d", code)
+	}
+
+	code, stdout, _ := runCli(t, "", nil, "-path", dbPath, "info")
+	if code != 0 || !strings.Contains(stdout, `"isPasswordProtected": true`) || strings.Contains(stdout, "encodedKey") {
+		t.Fatalf("unexpected info %d: %s", code, stdout)
+	}
+
+	if code, _, stderr := runCli(t, `{"title":"first note"}`, []string{"wrong"},
+		"-path", dbPath, "put", "-type", "Note", "1"); code != 1 || !strings.Contains(stderr, dodod.ErrWrongPassword.Error()) {
+		t.Fatalf("wrong password should fail %d: %s", code, stderr)
+	}
+
+	if code, _, stderr := runCli(t, `{"title":"first note"}`, []string{"secret"},
+		"-path", dbPath, "put", "-type", "Note", "1"); code != 0 {
+		t.Fatalf("unexpected put %d: %s", code, stderr)
+	}
+
+	if code, stdout, _ := runCli(t, "", []string{"secret"}, "-path", dbPath, "get", "1"); code != 0 ||
+		!strings.Contains(stdout, `"title": "first note"`) {
+		t.Fatalf("unexpected get %d: %s", code, stdout)
+	}
+
+	if code, stdout, _ := runCli(t, "", []string{"secret"}, "-path", dbPath, "count"); code != 0 || stdout != "1\n" {
+		t.Fatalf("unexpected count %d: %s", code, stdout)
+	}
+
+	queryFile := filepath.Join(dbPath, "query.json")
+	if err := ioutil.WriteFile(queryFile, []byte(`{"query":{"name":"Match","p":{"match":"first"}}}`), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code, stdout, stderr := runCli(t, "", []string{"secret"}, "-path", dbPath, "search", "-data", queryFile); code != 0 ||
+		!strings.Contains(stdout, `"total_hits": 1`) || !strings.Contains(stdout, `"title": "first note"`) {
+		t.Fatalf("unexpected search %d: %s %s", code, stdout, stderr)
+	}
+
+	code, exported, _ := runCli(t, "", []string{"secret"}, "-path", dbPath, "export")
+	if code != 0 || exported != `{"type":"Note","id":"1","data":{"title":"first note"}}`+"\n" {
+		t.Fatalf("unexpected export %d: %s", code, exported)
+	}
+
+	if code, _, _ := runCli(t, "", []string{"secret"}, "-path", dbPath, "delete", "-type", "Note", "1"); code != 0 {
+		t.Fatalf("unexpected delete exit code %d", code)
+	}
+	if code, _, _ := runCli(t, "", []string{"secret"}, "-path", dbPath, "get", "1"); code != 1 {
+		t.Fatalf("deleted document should not be found, exit code %d", code)
+	}
+
+	if code, _, stderr := runCli(t, exported, []string{"secret"}, "-path", dbPath, "import"); code != 0 ||
+		!strings.Contains(stderr, "imported 1 documents") {
+		t.Fatalf("unexpected import %d: %s", code, stderr)
+	}
+
+	if code, _, _ := runCli(t, "", []string{"secret"}, "-path", dbPath, "compact"); code != 0 {
+		t.Fatalf("unexpected compact exit code %d", code)
+	}
+
+	if code, _, _ := runCli(t, "", []string{"secret", "changed", "other"}, "-path", dbPath, "passwd"); code != 1 {
+		t.Fatalf("mismatched passwords should fail, exit code %d", code)
+	}
+	if code, _, stderr := runCli(t, "", []string{"secret", "changed", "changed"}, "-path", dbPath, "passwd"); code != 0 {
+		t.Fatalf("unexpected passwd %d: %s", code, stderr)
+	}
+
+	if code, stdout, _ := runCli(t, "", []string{"changed"}, "-path", dbPath, "count", "-type", "Note"); code != 0 || stdout != "1\n" {
+		t.Fatalf("unexpected count after password change %d: %s", code, stdout)
+	}
+}
+
+func TestCli_PasswdWithoutPassword(t *testing.T) {
+	dbPath := "/tmp/dodod-cli"
+	defer func() {
+		_ = os.RemoveAll(dbPath)
+	}()
+
+	newTestDatabase(t, dbPath, "")
+
+	if code, _, stderr := runCli(t, "", nil, "-path", dbPath, "passwd"); code != 1 ||
+		!strings.Contains(stderr, errNotPasswordProtected.Error()) {
+		t.Fatalf("passwd without a password should fail %d: %s", code, stderr)
+	}
+}
@@ -0,0 +1,265 @@
+package dodod
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// searchParams reads loosely typed values from a search input map,
+// such as a map produced by json.Unmarshal, and reports the full key
+// of any value which can not be coerced to the requested type
+type searchParams struct {
+	m      map[string]interface{}
+	prefix string
+}
+
+func newSearchParams(m map[string]interface{}, prefix string) *searchParams {
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return &searchParams{m: m, prefix: prefix}
+}
+
+func (p *searchParams) key(k string) string {
+	return p.prefix + k
+}
+
+func (p *searchParams) fail(k string, reason string) error {
+	return &SearchRequestError{Key: p.key(k), Reason: reason}
+}
+
+func (p *searchParams) lookup(k string) (interface{}, bool) {
+	v, found := p.m[k]
+	if !found || v == nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// String returns the string stored under k
+func (p *searchParams) String(k string) (string, bool, error) {
+	v, found := p.lookup(k)
+	if !found {
+		return "", false, nil
+	}
+	if s, ok := v.(string); ok {
+		return s, true, nil
+	}
+	return "", false, p.fail(k, "must be a string")
+}
+
+// Bool returns the boolean stored under k
+func (p *searchParams) Bool(k string) (bool, bool, error) {
+	v, found := p.lookup(k)
+	if !found {
+		return false, false, nil
+	}
+	if b, ok := v.(bool); ok {
+		return b, true, nil
+	}
+	return false, false, p.fail(k, "must be a boolean")
+}
+
+// Int returns the integer stored under k, a float64 is accepted
+// as long as it does not have a fractional part
+func (p *searchParams) Int(k string) (int, bool, error) {
+	v, found := p.lookup(k)
+	if !found {
+		return 0, false, nil
+	}
+	if i, ok := toInt(v); ok {
+		return i, true, nil
+	}
+	return 0, false, p.fail(k, "must be an integer")
+}
+
+// Float64 returns the number stored under k
+func (p *searchParams) Float64(k string) (float64, bool, error) {
+	v, found := p.lookup(k)
+	if !found {
+		return 0, false, nil
+	}
+	if f, ok := toFloat64(v); ok {
+		return f, true, nil
+	}
+	return 0, false, p.fail(k, "must be a number")
+}
+
+// Strings returns the string list stored under k,
+// both []string and []interface{} of strings are accepted
+func (p *searchParams) Strings(k string) ([]string, bool, error) {
+	v, found := p.lookup(k)
+	if !found {
+		return nil, false, nil
+	}
+
+	switch list := v.(type) {
+	case []string:
+		return list, true, nil
+	case []interface{}:
+		output := make([]string, 0, len(list))
+		for i, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false, p.fail(fmt.Sprintf("%s[%d]", k, i), "must be a string")
+			}
+			output = append(output, s)
+		}
+		return output, true, nil
+	default:
+		return nil, false, p.fail(k, "must be a list of strings")
+	}
+}
+
+// Map returns the object stored under k, both map[string]interface{}
+// and map[string]string are accepted
+func (p *searchParams) Map(k string) (map[string]interface{}, bool, error) {
+	v, found := p.lookup(k)
+	if !found {
+		return nil, false, nil
+	}
+	if m, ok := toMap(v); ok {
+		return m, true, nil
+	}
+	return nil, false, p.fail(k, "must be an object")
+}
+
+// Maps returns the object list stored under k
+func (p *searchParams) Maps(k string) ([]map[string]interface{}, bool, error) {
+	v, found := p.lookup(k)
+	if !found {
+		return nil, false, nil
+	}
+
+	switch list := v.(type) {
+	case []map[string]interface{}:
+		return list, true, nil
+	case []map[string]string:
+		output := make([]map[string]interface{}, 0, len(list))
+		for _, item := range list {
+			m, _ := toMap(item)
+			output = append(output, m)
+		}
+		return output, true, nil
+	case []interface{}:
+		output := make([]map[string]interface{}, 0, len(list))
+		for i, item := range list {
+			m, ok := toMap(item)
+			if !ok {
+				return nil, false, p.fail(fmt.Sprintf("%s[%d]", k, i), "must be an object")
+			}
+			output = append(output, m)
+		}
+		return output, true, nil
+	default:
+		return nil, false, p.fail(k, "must be a list of objects")
+	}
+}
+
+// Time returns the time stored under k either
+// as time.Time or as a RFC3339 string
+func (p *searchParams) Time(k string) (time.Time, bool, error) {
+	v, found := p.lookup(k)
+	if !found {
+		return time.Time{}, false, nil
+	}
+
+	switch t := v.(type) {
+	case time.Time:
+		return t, true, nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return time.Time{}, false, p.fail(k, "must be a RFC3339 date time")
+		}
+		return parsed, true, nil
+	default:
+		return time.Time{}, false, p.fail(k, "must be a RFC3339 date time")
+	}
+}
+
+func toMap(value interface{}) (map[string]interface{}, bool) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[string]string:
+		output := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			output[k] = v
+		}
+		return output, true
+	default:
+		return nil, false
+	}
+}
+
+// maxInt and minInt are the limits of int
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+// toInt converts the integral value to int
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		if v < int64(minInt) || v > int64(maxInt) {
+			return 0, false
+		}
+		return int(v), true
+	case uint:
+		if v > uint(maxInt) {
+			return 0, false
+		}
+		return int(v), true
+	case uint32:
+		if uint64(v) > uint64(maxInt) {
+			return 0, false
+		}
+		return int(v), true
+	case uint64:
+		if v > uint64(maxInt) {
+			return 0, false
+		}
+		return int(v), true
+	case float32:
+		return toInt(float64(v))
+	case float64:
+		// float64(maxInt) rounds up to the first value out of range
+		if v != math.Trunc(v) || v < float64(minInt) || v >= float64(maxInt) {
+			return 0, false
+		}
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
+
+// toFloat64 converts the numeric value to float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
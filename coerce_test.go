@@ -0,0 +1,147 @@
+package dodod
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/blevesearch/bleve"
+	"math"
+	"testing"
+)
+
+func TestNewSearchRequestFromMap_JSONDecoded(t *testing.T) {
+	t.Helper()
+
+	data := []byte(`{
+		"size": 5,
+		"from": 2,
+		"fields": ["*"],
+		"sort": ["_id"],
+		"highlight": {"style": "html", "fields": ["name"]},
+		"query": {"name": "Fuzzy", "p": {"term": "test", "fuzziness": 1, "prefix_length": 0}},
+		"facets": [{"name": "names", "field": "name", "size": 3,
+			"date_time_range": [{"name": "old", "end": "2020-01-01T00:00:00Z"}]}]
+	}`)
+
+	input := make(map[string]interface{})
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	request, err := NewSearchRequestFromMap(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if request.Size != 5 || request.From != 2 {
+		t.Fatalf("paging mismatch: %d, %d", request.Size, request.From)
+	}
+
+	if len(request.Fields) != 1 || len(request.Sort) != 1 {
+		t.Fatalf("fields or sort dropped")
+	}
+
+	if request.Highlight == nil || len(request.Highlight.Fields) != 1 {
+		t.Fatalf("highlight fields dropped")
+	}
+
+	if len(request.Facets) != 1 || request.Facets[0].Size != 3 || len(request.Facets[0].DateTimeRanges) != 1 {
+		t.Fatalf("facets dropped")
+	}
+}
+
+func TestNewSearchRequestFromMap_InvalidKey(t *testing.T) {
+	t.Helper()
+
+	testCases := []struct {
+		input map[string]interface{}
+		key   string
+	}{
+		{map[string]interface{}{"size": 1.5}, "size"},
+		{map[string]interface{}{"size": 1e20}, "size"},
+		{map[string]interface{}{"size": uint64(1 << 63)}, "size"},
+		{map[string]interface{}{"from": 2.5}, "from"},
+		{map[string]interface{}{"from": -1e20}, "from"},
+		{map[string]interface{}{"from": math.NaN()}, "from"},
+		{map[string]interface{}{"from": math.Inf(1)}, "from"},
+		{map[string]interface{}{"from": "1"}, "from"},
+		{map[string]interface{}{"fields": []interface{}{"a", 1.0}}, "fields[1]"},
+		{map[string]interface{}{"sort": "_id"}, "sort"},
+		{map[string]interface{}{"explain": "true"}, "explain"},
+		{map[string]interface{}{"query": "match all"}, "query"},
+		{map[string]interface{}{"query": map[string]interface{}{
+			"name": "Term",
+			"p":    map[string]interface{}{"term": 1.0},
+		}}, "query.p.term"},
+		{map[string]interface{}{"query": map[string]interface{}{
+			"name": "Fuzzy",
+			"p":    map[string]interface{}{"term": "a", "fuzziness": 0.5},
+		}}, "query.p.fuzziness"},
+		{map[string]interface{}{"facets": []interface{}{
+			map[string]interface{}{"name": "n", "field": "f", "size": "3"},
+		}}, "facets[0].size"},
+		{map[string]interface{}{"facets": []interface{}{"n"}}, "facets[0]"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := NewSearchRequestFromMap(testCase.input)
+		var requestError *SearchRequestError
+		if !errors.As(err, &requestError) {
+			t.Fatalf("expected error for key %s, found: %v", testCase.key, err)
+		}
+		if requestError.Key != testCase.key {
+			t.Fatalf("expected error for key %s, found: %s", testCase.key, requestError.Key)
+		}
+	}
+}
+
+func TestDatabase_SearchJSON(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.SearchJSON([]byte(`{}`), ""); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&MyTestDocument{Id: "2", Name: "second"},
+		&MyTestDocument{Id: "3", Name: "third"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if data, err := db.SearchJSON([]byte(`{"size": 1, "from": 1, "sort": ["_id"]}`), "bleveSearchResult"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else {
+		bleveSearchResult := data.(*bleve.SearchResult)
+		if len(bleveSearchResult.Hits) != 1 || bleveSearchResult.Hits[0].ID != "2" {
+			t.Fatalf("unexpected hits: %v", bleveSearchResult.Hits)
+		}
+	}
+
+	if _, err := db.SearchJSON([]byte(`{"size": "1"}`), ""); !errors.Is(err, ErrInvalidSearchRequest) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.SearchJSON([]byte(`{`), ""); err != ErrJSONParseFailed {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
@@ -2,13 +2,13 @@ package dodod
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"github.com/blevesearch/bleve"
 	"github.com/blevesearch/bleve/index/scorch"
 	"github.com/blevesearch/bleve/index/upsidedown"
 	"github.com/blevesearch/bleve/mapping"
-	"github.com/blevesearch/bleve/search/query"
 	"github.com/dgraph-io/badger/v2"
 	"github.com/dgraph-io/badger/v2/options"
 	"github.com/mkawserm/bdodb"
@@ -17,6 +17,7 @@ import (
 	"io/ioutil"
 	"os"
 	"reflect"
+	"sync"
 	"time"
 )
 
@@ -35,10 +36,25 @@ type Database struct {
 	isDbReady           bool
 	isReadOnly          bool
 
-	fieldsRegistryCache   map[string]string
-	documentRegistryCache map[string]interface{}
+	keyLayout          string
+	typeNamespacedKeys bool
 
-	internalIndexMapping   *mapping.IndexMappingImpl
+	expirySweepInterval time.Duration
+	sweeperStop         chan struct{}
+	sweeperDone         chan struct{}
+
+	// clock returns the time used for document expiry, nil means time.Now
+	clock func() time.Time
+
+	fieldsRegistryCache         map[string]string
+	documentRegistryCache       map[string]interface{}
+	secondaryIndexRegistryCache map[string][]*secondaryIndexField
+
+	internalIndexMapping *mapping.IndexMappingImpl
+
+	// indexLock is held for reading while the index store is used
+	// and for writing while Reindex swaps it
+	indexLock              sync.RWMutex
 	internalIndex          bleve.Index
 	internalDb             *badger.DB
 	internalIndexStoreName string
@@ -53,6 +69,9 @@ func (db *Database) GetInternalDatabase() *badger.DB {
 }
 
 func (db *Database) GetInternalIndex() bleve.Index {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
 	return db.internalIndex
 }
 
@@ -72,6 +91,14 @@ func (db *Database) initAll() {
 	if db.documentRegistryCache == nil {
 		db.documentRegistryCache = make(map[string]interface{})
 	}
+
+	if db.secondaryIndexRegistryCache == nil {
+		db.secondaryIndexRegistryCache = make(map[string][]*secondaryIndexField)
+	}
+
+	if db.keyLayout == "" {
+		db.keyLayout = KeyLayoutId
+	}
 }
 
 func (db *Database) SetDbPath(dbPath string) {
@@ -154,23 +181,61 @@ func (db *Database) Open() error {
 			return readError
 		}
 	} else {
+		if db.typeNamespacedKeys {
+			db.keyLayout = KeyLayoutType
+		}
+
 		if _, writeError := db.writeConfig(); writeError != nil {
 			return writeError
 		}
 	}
 
-	return db.openDb()
+	if err := db.openDb(); err != nil {
+		return err
+	}
+
+	if n, err := db.ReplayIntentLog(); err != nil {
+		DefaultLogger.Errorf("failed to replay intent log: %v", err)
+		_ = db.Close()
+		return err
+	} else if n > 0 {
+		DefaultLogger.Infof("replayed %d pending index intents", n)
+	}
+
+	// an interrupted migration is resumed even without the opt in
+	migrationStarted, err := db.isKeyLayoutMigrationStarted()
+	if err != nil {
+		_ = db.Close()
+		return err
+	}
+
+	if (db.typeNamespacedKeys || migrationStarted) && db.keyLayout != KeyLayoutType && !db.isReadOnly {
+		n, err := db.MigrateToTypeNamespacedKeys()
+		if err != nil {
+			_ = db.Close()
+			return err
+		}
+		DefaultLogger.Infof("migrated %d documents to type namespaced keys", n)
+	}
+
+	db.startExpirySweeper()
+
+	return nil
 }
 
 func (db *Database) Close() error {
+	db.stopExpirySweeper()
 	db.isDbReady = false
 
 	//var err1 error
 	//var err2 error
 
+	db.indexLock.Lock()
 	if db.internalIndex != nil {
 		_ = db.internalIndex.Close()
 	}
+	db.indexLock.Unlock()
+
 	if db.internalDb != nil {
 		_ = db.internalDb.Close()
 	}
@@ -237,6 +302,10 @@ func (db *Database) RegisterDocument(d interface{}) error {
 
 	db.documentRegistryCache[document.Type()] = document
 
+	if indexFields := extractSecondaryIndexFields(document); len(indexFields) > 0 {
+		db.secondaryIndexRegistryCache[document.Type()] = indexFields
+	}
+
 	return nil
 }
 
@@ -257,6 +326,23 @@ func (db *Database) IsDatabaseReady() bool {
 }
 
 func (db *Database) EncodeDocument(document interface{}) ([]byte, error) {
+	return db.EncodeDocumentWithVersion(document, 0)
+}
+
+// withVersion appends the version to an envelope encoded without one
+func withVersion(encoded []byte, version uint64) []byte {
+	if version == 0 {
+		return encoded
+	}
+
+	versionBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(versionBytes, version)
+	return append(encoded, versionBytes...)
+}
+
+// EncodeDocumentWithVersion encodes the document into the envelope stored in
+// the database followed by the document version, version zero is omitted
+func (db *Database) EncodeDocumentWithVersion(document interface{}, version uint64) ([]byte, error) {
 	var err error
 	var jsonData []byte
 
@@ -290,7 +376,7 @@ func (db *Database) EncodeDocument(document interface{}) ([]byte, error) {
 	output.Write(dataLengthBytes)
 	output.Write(jsonData)
 
-	return output.Bytes(), nil
+	return withVersion(output.Bytes(), version), nil
 }
 
 func (db *Database) DecodeDocument(data []byte) (interface{}, error) {
@@ -319,7 +405,7 @@ func (db *Database) DecodeDocument(data []byte) (interface{}, error) {
 		doc = newIndirect.Interface()
 	}
 
-	jsonData := data[4+documentTypeLength+4:]
+	jsonData, _ := envelopeData(data, documentTypeLength)
 	if err := json.Unmarshal(jsonData, &doc); err != nil {
 		return nil, err
 	}
@@ -341,7 +427,7 @@ func (db *Database) DecodeDocumentUsingInterface(data []byte, document interface
 		return ErrInvalidData
 	}
 
-	jsonData := data[4+documentTypeLength+4:]
+	jsonData, _ := envelopeData(data, documentTypeLength)
 	if err := json.Unmarshal(jsonData, document); err != nil {
 		return err
 	}
@@ -349,7 +435,133 @@ func (db *Database) DecodeDocumentUsingInterface(data []byte, document interface
 	return nil
 }
 
+// putDocument stores the document under the key inside the transaction
+// with the next version and maintains the secondary indexes of the document
+func (db *Database) putDocument(txn *badger.Txn, key string, d interface{}) error {
+	version, err := db.nextVersion(txn, key)
+	if err != nil {
+		return err
+	}
+
+	return db.putDocumentWithVersion(txn, key, d, version)
+}
+
+// putDocumentWithVersion stores the document with the given version
+func (db *Database) putDocumentWithVersion(txn *badger.Txn, key string, d interface{}, version uint64) error {
+	write, err := db.prepareDocumentWrite(txn, key, d, version)
+	if err != nil {
+		return err
+	}
+
+	return db.writeDocument(txn, write)
+}
+
+// documentWrite is a checked document ready to be written
+type documentWrite struct {
+	key     string
+	doc     interface{}
+	encoded []byte
+	entries []*secondaryIndexEntry
+	version uint64
+	created bool
+}
+
+// prepareDocumentWrite encodes the document and checks its unique values
+// without changing the transaction
+func (db *Database) prepareDocumentWrite(txn *badger.Txn, key string, d interface{}, version uint64) (*documentWrite, error) {
+	if isInternalKey([]byte(key)) {
+		return nil, ErrIdIsReserved
+	}
+
+	jsonData, err := db.EncodeDocumentWithVersion(d, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.prepareEncodedDocumentWrite(txn, key, d, jsonData, version)
+}
+
+// prepareEncodedDocumentWrite is prepareDocumentWrite for a document
+// which is already encoded with the version
+func (db *Database) prepareEncodedDocumentWrite(txn *badger.Txn,
+	key string,
+	d interface{},
+	jsonData []byte,
+	version uint64) (*documentWrite, error) {
+
+	entries, err := db.secondaryIndexEntries(txn, key, d)
+	if err != nil {
+		return nil, err
+	}
+
+	created := false
+	if _, err := txn.Get([]byte(key)); err == badger.ErrKeyNotFound {
+		created = true
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &documentWrite{key: key, doc: d, encoded: jsonData, entries: entries, version: version, created: created}, nil
+}
+
+// writeDocument writes the prepared document and its secondary indexes
+func (db *Database) writeDocument(txn *badger.Txn, write *documentWrite) error {
+	if err := db.removeSecondaryIndexes(txn, write.key); err != nil {
+		return err
+	}
+
+	if err := db.writeSecondaryIndexes(txn, write.key, write.entries); err != nil {
+		return err
+	}
+
+	if err := setLastVersion(txn, write.key, write.version); err != nil {
+		return err
+	}
+
+	return db.setDocumentEntry(txn, write.key, write.encoded, write.doc, write.created)
+}
+
+// removeDocument deletes the document stored under the key
+// and its secondary indexes inside the transaction
+func (db *Database) removeDocument(txn *badger.Txn, key string) error {
+	if isInternalKey([]byte(key)) {
+		return ErrIdIsReserved
+	}
+
+	if err := db.removeSecondaryIndexes(txn, key); err != nil {
+		return err
+	}
+
+	return txn.Delete([]byte(key))
+}
+
+// commitError maps the error of a failed commit, a conflict with a
+// concurrent transaction is returned as is so that the write can be retried
+func commitError(err error) error {
+	if err == badger.ErrConflict {
+		return err
+	}
+
+	return ErrDatabaseTransactionFailed
+}
+
+// Create stores and indexes the documents, existing documents are
+// replaced the same way as Upsert, use Insert to reject existing ids
 func (db *Database) Create(data []interface{}) error {
+	return db.CreateContext(context.Background(), data)
+}
+
+// CreateContext is the context aware variant of Create
+func (db *Database) CreateContext(ctx context.Context, data []interface{}) error {
+	return db.createContext(ctx, data, false)
+}
+
+// createContext stores and indexes the documents, with insertOnly the
+// documents must not exist and the check is done inside the same transaction
+func (db *Database) createContext(ctx context.Context, data []interface{}, insertOnly bool) error {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
 	if !db.IsDatabaseReady() {
 		return ErrDatabaseIsNotOpen
 	}
@@ -361,10 +573,18 @@ func (db *Database) Create(data []interface{}) error {
 	defer internalBatchTxn.Discard()
 
 	batch := db.internalIndex.NewBatch()
+	ids := make([]string, 0, len(data))
+	existingIds := make([]string, 0)
 	for _, d := range data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var id string
+		var key string
 		if n, ok := d.(Document); ok {
 			id = n.GetId()
+			key = db.documentKey(n)
 		} else {
 			return ErrInvalidDocument
 		}
@@ -373,33 +593,65 @@ func (db *Database) Create(data []interface{}) error {
 			return ErrIdCanNotBeEmpty
 		}
 
-		if jsonData, err := db.EncodeDocument(d); err == nil {
-			if err := internalBatchTxn.Set([]byte(id), jsonData); err != nil {
+		if insertOnly {
+			if _, err := internalBatchTxn.Get([]byte(key)); err == nil {
+				existingIds = append(existingIds, id)
+				continue
+			} else if err != badger.ErrKeyNotFound {
 				return err
 			}
-		} else {
+		}
+
+		ids = append(ids, key)
+
+		if err := db.putDocument(internalBatchTxn, key, d); err != nil {
 			return err
 		}
 
-		if err := batch.Index(id, d); err != nil {
+		if err := batch.Index(key, indexValue(d)); err != nil {
 			return err
 		}
 	}
 
+	if len(existingIds) > 0 {
+		return &DocumentAlreadyExistsError{Ids: existingIds}
+	}
+
+	intentKey, err := db.writeIndexIntent(internalBatchTxn, ids)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	err1 = internalBatchTxn.Commit()
 	if err1 != nil {
-		return ErrDatabaseTransactionFailed
+		return commitError(err1)
 	}
 
+	// the intent stays in the database on failure and
+	// will be replayed by ReplayIntentLog
 	err2 = db.internalIndex.Batch(batch)
 	if err2 != nil {
 		return ErrIndexStoreTransactionFailed
 	}
 
+	db.clearIndexIntent(intentKey)
+
 	return nil
 }
 
 func (db *Database) Update(data []interface{}) error {
+	return db.UpdateContext(context.Background(), data)
+}
+
+// UpdateContext is the context aware variant of Update
+func (db *Database) UpdateContext(ctx context.Context, data []interface{}) error {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
 	if !db.IsDatabaseReady() {
 		return ErrDatabaseIsNotOpen
 	}
@@ -411,10 +663,17 @@ func (db *Database) Update(data []interface{}) error {
 	defer internalBatchTxn.Discard()
 
 	batch := db.internalIndex.NewBatch()
+	ids := make([]string, 0, len(data))
 	for _, d := range data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var id string
+		var key string
 		if n, ok := d.(Document); ok {
 			id = n.GetId()
+			key = db.documentKey(n)
 		} else {
 			return ErrInvalidDocument
 		}
@@ -423,38 +682,53 @@ func (db *Database) Update(data []interface{}) error {
 			return ErrIdCanNotBeEmpty
 		}
 
-		if err := internalBatchTxn.Delete([]byte(id)); err != nil {
-			return err
-		}
+		ids = append(ids, key)
 
-		if jsonData, err := db.EncodeDocument(d); err == nil {
-			if err := internalBatchTxn.Set([]byte(id), jsonData); err != nil {
-				return err
-			}
-		} else {
+		if err := db.putDocument(internalBatchTxn, key, d); err != nil {
 			return err
 		}
 
-		// batch.Delete(id)
-		if err := batch.Index(id, d); err != nil {
+		// batch.Delete(key)
+		if err := batch.Index(key, indexValue(d)); err != nil {
 			return err
 		}
 	}
 
+	intentKey, err := db.writeIndexIntent(internalBatchTxn, ids)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	err1 = internalBatchTxn.Commit()
 	if err1 != nil {
-		return ErrDatabaseTransactionFailed
+		return commitError(err1)
 	}
 
+	// the intent stays in the database on failure and
+	// will be replayed by ReplayIntentLog
 	err2 = db.internalIndex.Batch(batch)
 	if err2 != nil {
 		return ErrIndexStoreTransactionFailed
 	}
 
+	db.clearIndexIntent(intentKey)
+
 	return nil
 }
 
 func (db *Database) Delete(data []interface{}) error {
+	return db.DeleteContext(context.Background(), data)
+}
+
+// DeleteContext is the context aware variant of Delete
+func (db *Database) DeleteContext(ctx context.Context, data []interface{}) error {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
 	if !db.IsDatabaseReady() {
 		return ErrDatabaseIsNotOpen
 	}
@@ -466,10 +740,17 @@ func (db *Database) Delete(data []interface{}) error {
 	defer internalBatchTxn.Discard()
 
 	batch := db.internalIndex.NewBatch()
+	ids := make([]string, 0, len(data))
 	for _, d := range data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var id string
+		var key string
 		if n, ok := d.(Document); ok {
 			id = n.GetId()
+			key = db.documentKey(n)
 		} else {
 			return ErrInvalidDocument
 		}
@@ -478,27 +759,47 @@ func (db *Database) Delete(data []interface{}) error {
 			return ErrIdCanNotBeEmpty
 		}
 
-		if err := internalBatchTxn.Delete([]byte(id)); err != nil {
+		ids = append(ids, key)
+
+		if err := db.removeDocument(internalBatchTxn, key); err != nil {
 			return err
 		}
 
-		batch.Delete(id)
+		batch.Delete(key)
+	}
+
+	intentKey, err := db.writeIndexIntent(internalBatchTxn, ids)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	err1 = internalBatchTxn.Commit()
 	if err1 != nil {
-		return ErrDatabaseTransactionFailed
+		return commitError(err1)
 	}
 
+	// the intent stays in the database on failure and
+	// will be replayed by ReplayIntentLog
 	err2 = db.internalIndex.Batch(batch)
 	if err2 != nil {
 		return ErrIndexStoreTransactionFailed
 	}
 
+	db.clearIndexIntent(intentKey)
+
 	return nil
 }
 
 func (db *Database) CreateDocument(data []interface{}) error {
+	return db.CreateDocumentContext(context.Background(), data)
+}
+
+// CreateDocumentContext is the context aware variant of CreateDocument
+func (db *Database) CreateDocumentContext(ctx context.Context, data []interface{}) error {
 	if !db.IsDatabaseReady() {
 		return ErrDatabaseIsNotOpen
 	}
@@ -509,9 +810,15 @@ func (db *Database) CreateDocument(data []interface{}) error {
 	defer internalBatchTxn.Discard()
 
 	for _, d := range data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var id string
+		var key string
 		if n, ok := d.(Document); ok {
 			id = n.GetId()
+			key = db.documentKey(n)
 		} else {
 			return ErrInvalidDocument
 		}
@@ -520,25 +827,30 @@ func (db *Database) CreateDocument(data []interface{}) error {
 			return ErrIdCanNotBeEmpty
 		}
 
-		if jsonData, err := db.EncodeDocument(d); err == nil {
-			if err := internalBatchTxn.Set([]byte(id), jsonData); err != nil {
-				return err
-			}
-		} else {
+		if err := db.putDocument(internalBatchTxn, key, d); err != nil {
 			return err
 		}
 
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	err1 = internalBatchTxn.Commit()
 	if err1 != nil {
-		return ErrDatabaseTransactionFailed
+		return commitError(err1)
 	}
 
 	return nil
 }
 
 func (db *Database) UpdateDocument(data []interface{}) error {
+	return db.UpdateDocumentContext(context.Background(), data)
+}
+
+// UpdateDocumentContext is the context aware variant of UpdateDocument
+func (db *Database) UpdateDocumentContext(ctx context.Context, data []interface{}) error {
 	if !db.IsDatabaseReady() {
 		return ErrDatabaseIsNotOpen
 	}
@@ -549,9 +861,15 @@ func (db *Database) UpdateDocument(data []interface{}) error {
 	defer internalBatchTxn.Discard()
 
 	for _, d := range data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var id string
+		var key string
 		if n, ok := d.(Document); ok {
 			id = n.GetId()
+			key = db.documentKey(n)
 		} else {
 			return ErrInvalidDocument
 		}
@@ -560,28 +878,29 @@ func (db *Database) UpdateDocument(data []interface{}) error {
 			return ErrIdCanNotBeEmpty
 		}
 
-		if err := internalBatchTxn.Delete([]byte(id)); err != nil {
+		if err := db.putDocument(internalBatchTxn, key, d); err != nil {
 			return err
 		}
+	}
 
-		if jsonData, err := db.EncodeDocument(d); err == nil {
-			if err := internalBatchTxn.Set([]byte(id), jsonData); err != nil {
-				return err
-			}
-		} else {
-			return err
-		}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	err1 = internalBatchTxn.Commit()
 	if err1 != nil {
-		return ErrDatabaseTransactionFailed
+		return commitError(err1)
 	}
 
 	return nil
 }
 
 func (db *Database) DeleteDocument(data []interface{}) error {
+	return db.DeleteDocumentContext(context.Background(), data)
+}
+
+// DeleteDocumentContext is the context aware variant of DeleteDocument
+func (db *Database) DeleteDocumentContext(ctx context.Context, data []interface{}) error {
 	if !db.IsDatabaseReady() {
 		return ErrDatabaseIsNotOpen
 	}
@@ -592,9 +911,15 @@ func (db *Database) DeleteDocument(data []interface{}) error {
 	defer internalBatchTxn.Discard()
 
 	for _, d := range data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var id string
+		var key string
 		if n, ok := d.(Document); ok {
 			id = n.GetId()
+			key = db.documentKey(n)
 		} else {
 			return ErrInvalidDocument
 		}
@@ -603,20 +928,29 @@ func (db *Database) DeleteDocument(data []interface{}) error {
 			return ErrIdCanNotBeEmpty
 		}
 
-		if err := internalBatchTxn.Delete([]byte(id)); err != nil {
+		if err := db.removeDocument(internalBatchTxn, key); err != nil {
 			return err
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	err1 = internalBatchTxn.Commit()
 	if err1 != nil {
-		return ErrDatabaseTransactionFailed
+		return commitError(err1)
 	}
 
 	return nil
 }
 
 func (db *Database) Read(data []string) (uint64, []interface{}, error) {
+	return db.ReadContext(context.Background(), data)
+}
+
+// ReadContext is the context aware variant of Read
+func (db *Database) ReadContext(ctx context.Context, data []string) (uint64, []interface{}, error) {
 	if !db.IsDatabaseReady() {
 		return 0, nil, ErrDatabaseIsNotOpen
 	}
@@ -627,6 +961,10 @@ func (db *Database) Read(data []string) (uint64, []interface{}, error) {
 	output := make([]interface{}, len(data), len(data))
 	var readCount = 0
 	for _, id := range data {
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+
 		if id == "" {
 			continue
 		}
@@ -659,6 +997,11 @@ func (db *Database) IsDocumentExists(id string) bool {
 }
 
 func (db *Database) GetDocument(data []interface{}) (uint64, error) {
+	return db.GetDocumentContext(context.Background(), data)
+}
+
+// GetDocumentContext is the context aware variant of GetDocument
+func (db *Database) GetDocumentContext(ctx context.Context, data []interface{}) (uint64, error) {
 	if !db.IsDatabaseReady() {
 		return 0, ErrDatabaseIsNotOpen
 	}
@@ -668,16 +1011,22 @@ func (db *Database) GetDocument(data []interface{}) (uint64, error) {
 
 	var readCount uint64 = 0
 	for _, d := range data {
+		if err := ctx.Err(); err != nil {
+			return readCount, err
+		}
+
 		var id string
+		var key string
 		if n, ok := d.(Document); ok {
 			id = n.GetId()
+			key = db.documentKey(n)
 		}
 
 		if id == "" {
 			continue
 		}
 
-		if item, err := internalBatchTxn.Get([]byte(id)); err == nil {
+		if item, err := internalBatchTxn.Get([]byte(key)); err == nil {
 			if value, err := item.ValueCopy(nil); err == nil {
 				if err := db.DecodeDocumentUsingInterface(value, d); err == nil {
 					readCount = readCount + 1
@@ -700,6 +1049,11 @@ func (db *Database) GetDocument(data []interface{}) (uint64, error) {
 }
 
 func (db *Database) GetDocumentWithError(data []string) (uint64, []interface{}, error) {
+	return db.GetDocumentWithErrorContext(context.Background(), data)
+}
+
+// GetDocumentWithErrorContext is the context aware variant of GetDocumentWithError
+func (db *Database) GetDocumentWithErrorContext(ctx context.Context, data []string) (uint64, []interface{}, error) {
 	if !db.IsDatabaseReady() {
 		return 0, nil, ErrDatabaseIsNotOpen
 	}
@@ -710,6 +1064,10 @@ func (db *Database) GetDocumentWithError(data []string) (uint64, []interface{},
 	output := make([]interface{}, len(data), len(data))
 	var readCount uint64 = 0
 	for i, id := range data {
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+
 		if id == "" {
 			continue
 		}
@@ -734,15 +1092,29 @@ func (db *Database) GetDocumentWithError(data []string) (uint64, []interface{},
 }
 
 func (db *Database) CreateIndex(data []interface{}) error {
+	return db.CreateIndexContext(context.Background(), data)
+}
+
+// CreateIndexContext is the context aware variant of CreateIndex
+func (db *Database) CreateIndexContext(ctx context.Context, data []interface{}) error {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
 	if !db.IsDatabaseReady() {
 		return ErrDatabaseIsNotOpen
 	}
 
 	batch := db.internalIndex.NewBatch()
 	for _, d := range data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var id string
+		var key string
 		if n, ok := d.(Document); ok {
 			id = n.GetId()
+			key = db.documentKey(n)
 		} else {
 			return ErrInvalidDocument
 		}
@@ -750,62 +1122,105 @@ func (db *Database) CreateIndex(data []interface{}) error {
 			return ErrIdCanNotBeEmpty
 		}
 
-		if err := batch.Index(id, d); err != nil {
+		if err := batch.Index(key, indexValue(d)); err != nil {
 			return err
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	return db.internalIndex.Batch(batch)
 }
 
 func (db *Database) UpdateIndex(data []interface{}) error {
+	return db.UpdateIndexContext(context.Background(), data)
+}
+
+// UpdateIndexContext is the context aware variant of UpdateIndex
+func (db *Database) UpdateIndexContext(ctx context.Context, data []interface{}) error {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
 	if !db.IsDatabaseReady() {
 		return ErrDatabaseIsNotOpen
 	}
 
 	batch := db.internalIndex.NewBatch()
 	for _, d := range data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var id string
+		var key string
 		if n, ok := d.(Document); ok {
 			id = n.GetId()
+			key = db.documentKey(n)
 		} else {
 			return ErrInvalidDocument
 		}
 		if id == "" {
 			return ErrIdCanNotBeEmpty
 		}
-		batch.Delete(id)
-		if err := batch.Index(id, d); err != nil {
+		batch.Delete(key)
+		if err := batch.Index(key, indexValue(d)); err != nil {
 			return err
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	return db.internalIndex.Batch(batch)
 }
 
 func (db *Database) DeleteIndex(data []interface{}) error {
+	return db.DeleteIndexContext(context.Background(), data)
+}
+
+// DeleteIndexContext is the context aware variant of DeleteIndex
+func (db *Database) DeleteIndexContext(ctx context.Context, data []interface{}) error {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
 	if !db.IsDatabaseReady() {
 		return ErrDatabaseIsNotOpen
 	}
 
 	batch := db.internalIndex.NewBatch()
 	for _, d := range data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var id string
+		var key string
 		if n, ok := d.(Document); ok {
 			id = n.GetId()
+			key = db.documentKey(n)
 		} else {
 			return ErrInvalidDocument
 		}
 		if id == "" {
 			return ErrIdCanNotBeEmpty
 		}
-		batch.Delete(id)
+		batch.Delete(key)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	return db.internalIndex.Batch(batch)
 }
 
 func (db *Database) IsIndexExists(id string) bool {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
 	if !db.IsDatabaseReady() {
 		return false
 	}
@@ -819,250 +1234,64 @@ func (db *Database) IsIndexExists(id string) bool {
 
 // Search using the input params into the index store
 func (db *Database) Search(input map[string]interface{}, outputType string) (interface{}, error) {
+	return db.SearchContext(context.Background(), input, outputType)
+}
 
-	var bleveQuery query.Query
-
-	if val, valOk := input["query"].(map[string]interface{}); valOk {
-		p := map[string]interface{}{}
-		if pVal, pOk := val["p"].(map[string]interface{}); pOk && pVal != nil {
-			p = pVal
-		}
-
-		if name, ok := val["name"].(string); ok {
-
-			// Switch to bleveQuery type
-			switch name {
-
-			case "QueryString":
-				if q, qFound := p["q"].(string); qFound {
-					queryStringQuery := bleve.NewQueryStringQuery(q)
-
-					bleveQuery = queryStringQuery
-				}
-
-			case "Fuzzy":
-				if term, termFound := p["term"].(string); termFound {
-					fuzzyQuery := bleve.NewFuzzyQuery(term)
-
-					if fuzziness, fuzzinessFound := p["fuzziness"].(int); fuzzinessFound {
-						fuzzyQuery.Fuzziness = fuzziness
-					}
-
-					if field, fieldFound := p["field"].(string); fieldFound {
-						fuzzyQuery.SetField(field)
-					}
-
-					if prefixLength, prefixLengthFound := p["prefix_length"].(int); prefixLengthFound {
-						fuzzyQuery.SetPrefix(prefixLength)
-					}
-
-					bleveQuery = fuzzyQuery
-				}
-
-			case "Regexp":
-				if regexp, regexpFound := p["regexp"].(string); regexpFound {
-					regexpQuery := bleve.NewRegexpQuery(regexp)
-					if field, fieldFound := p["field"].(string); fieldFound {
-						regexpQuery.SetField(field)
-					}
-					bleveQuery = regexpQuery
-				}
-
-			case "Term":
-				if term, termFound := p["term"].(string); termFound {
-					termQuery := bleve.NewTermQuery(term)
-					if field, fieldFound := p["field"].(string); fieldFound {
-						termQuery.SetField(field)
-					}
-					bleveQuery = termQuery
-				}
-
-			case "MatchPhrase":
-				if matchPhrase, matchPhraseFound := p["match_phrase"].(string); matchPhraseFound {
-					matchPhraseQuery := bleve.NewMatchPhraseQuery(matchPhrase)
-
-					if field, fieldFound := p["field"].(string); fieldFound {
-						matchPhraseQuery.SetField(field)
-					}
-					bleveQuery = matchPhraseQuery
-				}
-
-			case "Match":
-				if match, matchFound := p["match"].(string); matchFound {
-					matchQuery := bleve.NewMatchQuery(match)
-
-					if field, fieldFound := p["field"].(string); fieldFound {
-						matchQuery.SetField(field)
-					}
-
-					bleveQuery = matchQuery
-				}
-
-			case "Prefix":
-				if prefix, prefixFound := p["prefix"].(string); prefixFound {
-					prefixQuery := bleve.NewPrefixQuery(prefix)
-
-					if field, fieldFound := p["field"].(string); fieldFound {
-						prefixQuery.SetField(field)
-					}
-
-					bleveQuery = prefixQuery
-				}
-
-			case "Wildcard":
-				if wildcard, wildcardFound := p["wildcard"].(string); wildcardFound {
-					wildcardQuery := bleve.NewWildcardQuery(wildcard)
-
-					if field, fieldFound := p["field"].(string); fieldFound {
-						wildcardQuery.SetField(field)
-					}
-
-					bleveQuery = wildcardQuery
-				}
-
-			case "GeoDistance":
-				if lon, lonFound := p["lon"].(float64); lonFound {
-					if lat, latFound := p["lat"].(float64); latFound {
-						if distance, distanceFound := p["distance"].(string); distanceFound {
-							geoDistanceQuery := bleve.NewGeoDistanceQuery(lon, lat, distance)
-							if field, fieldFound := p["field"].(string); fieldFound {
-								geoDistanceQuery.SetField(field)
-							}
-							bleveQuery = geoDistanceQuery
-						}
-					}
-				}
-			}
-			// Switch end
-		}
+// SearchContext is the context aware variant of Search,
+// the search is aborted once the context is done
+func (db *Database) SearchContext(ctx context.Context, input map[string]interface{}, outputType string) (interface{}, error) {
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
 	}
 
-	if bleveQuery == nil {
-		bleveQuery = bleve.NewMatchAllQuery()
+	request, err := NewSearchRequestFromMap(input)
+	if err != nil {
+		return nil, err
 	}
 
-	searchRequest := bleve.NewSearchRequest(bleveQuery)
+	return db.SearchWithRequestContext(ctx, request, outputType)
+}
 
-	if size, ok := input["size"].(int); ok {
-		searchRequest.Size = size
-	}
-	if from, ok := input["from"].(int); ok {
-		searchRequest.From = from
-	}
-	if fields, ok := input["fields"].([]string); ok {
-		searchRequest.Fields = fields
-	}
-	if explain, ok := input["explain"].(bool); ok {
-		searchRequest.Explain = explain
-	}
-	if sort, ok := input["sort"].([]string); ok {
-		searchRequest.SortBy(sort)
-	}
-	if includeLocations, ok := input["include_locations"].(bool); ok {
-		searchRequest.IncludeLocations = includeLocations
-	}
-	if score, ok := input["score"].(string); ok {
-		searchRequest.Score = score
-	}
-	if searchAfter, ok := input["search_after"].([]string); ok {
-		searchRequest.SearchAfter = searchAfter
-	}
-	if searchBefore, ok := input["search_before"].([]string); ok {
-		searchRequest.SearchBefore = searchBefore
-	}
+// SearchJSON using the JSON encoded input params into the index store,
+// the input has the same shape as the Search input map
+func (db *Database) SearchJSON(input []byte, outputType string) (interface{}, error) {
+	return db.SearchJSONContext(context.Background(), input, outputType)
+}
 
-	if highlight, highlightFound := input["highlight"].(map[string]interface{}); highlightFound {
-		searchRequest.Highlight = bleve.NewHighlight()
-		if style, styleFound := highlight["style"].(string); styleFound {
-			searchRequest.Highlight.Style = &style
-		}
-		if fields, fieldsFound := highlight["fields"].([]string); fieldsFound {
-			searchRequest.Highlight.Fields = fields
-		}
+// SearchJSONContext is the context aware variant of SearchJSON
+func (db *Database) SearchJSONContext(ctx context.Context, input []byte, outputType string) (interface{}, error) {
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
 	}
 
-	// facets section
-	if facets, facetsFound := input["facets"].([]interface{}); facetsFound {
-
-		for _, singleFacetInterface := range facets {
-			singleFacet := map[string]interface{}{}
-
-			if v, ok := singleFacetInterface.(map[string]interface{}); ok {
-				singleFacet = v
-			}
+	request, err := NewSearchRequestFromJSON(input)
+	if err != nil {
+		return nil, err
+	}
 
-			if name, nameFound := singleFacet["name"].(string); nameFound {
-				if field, fieldFound := singleFacet["field"].(string); fieldFound {
-					if size, sizeFound := singleFacet["size"].(int); sizeFound {
-						facetRequest := bleve.NewFacetRequest(field, size)
-
-						if dateTimeRangeList, dateTimeRangeListFound := singleFacet["date_time_range"].([]interface{}); dateTimeRangeListFound {
-							for _, dateTimeRangeInterface := range dateTimeRangeList {
-								dateTimeRange := map[string]string{}
-								if v, ok := dateTimeRangeInterface.(map[string]string); ok {
-									dateTimeRange = v
-								}
-
-								dateTimeRangeName := ""
-								dateTimeRangeStart := ""
-								dateTimeRangeEnd := ""
-								if v, found := dateTimeRange["name"]; found {
-									dateTimeRangeName = v
-								}
-								if v, found := dateTimeRange["start"]; found {
-									dateTimeRangeStart = v
-								}
-								if v, found := dateTimeRange["end"]; found {
-									dateTimeRangeEnd = v
-								}
-								if dateTimeRangeName != "" {
-									facetRequest.AddDateTimeRangeString(dateTimeRangeName,
-										&dateTimeRangeStart,
-										&dateTimeRangeEnd)
-								}
+	return db.SearchWithRequestContext(ctx, request, outputType)
+}
 
-							}
-						}
+// SearchWithRequest using the typed search request into the index store
+func (db *Database) SearchWithRequest(request *SearchRequest, outputType string) (interface{}, error) {
+	return db.SearchWithRequestContext(context.Background(), request, outputType)
+}
 
-						if numericRangeList, numericRangeListFound := singleFacet["numeric_range"].([]interface{}); numericRangeListFound {
-							for _, numericRangeInterface := range numericRangeList {
-
-								numericRange := map[string]interface{}{}
-								if v, ok := numericRangeInterface.(map[string]interface{}); ok {
-									numericRange = v
-								}
-
-								numericRangeName := ""
-								var numericRangeMin float64
-								var numericRangeMax float64
-
-								if v, found := numericRange["name"].(string); found {
-									numericRangeName = v
-								}
-								if v, found := numericRange["min"].(float64); found {
-									numericRangeMin = v
-								}
-								if v, found := numericRange["max"].(float64); found {
-									numericRangeMax = v
-								}
-
-								if numericRangeName != "" {
-									facetRequest.AddNumericRange(numericRangeName,
-										&numericRangeMin,
-										&numericRangeMax)
-								}
+// SearchWithRequestContext is the context aware variant of SearchWithRequest
+func (db *Database) SearchWithRequestContext(ctx context.Context, request *SearchRequest, outputType string) (interface{}, error) {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
 
-							}
-						}
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
 
-						searchRequest.AddFacet(name, facetRequest)
-					}
-				}
-			}
-		}
+	searchRequest, err := request.ToBleveSearchRequest()
+	if err != nil {
+		return nil, err
 	}
 
-	searchResult, err := db.internalIndex.Search(searchRequest)
+	searchResult, err := db.internalIndex.SearchInContext(ctx, searchRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -1089,10 +1318,12 @@ func (db *Database) Search(input map[string]interface{}, outputType string) (int
 			for _, hitInterface := range hitsList {
 				if hit, hitFound := hitInterface.(map[string]interface{}); hitFound {
 					if id, idFound := hit["id"].(string); id != "" && idFound {
-						if total, data, readError := db.Read([]string{id}); readError == nil {
+						if total, data, readError := db.ReadContext(ctx, []string{id}); readError == nil {
 							if total == 1 && len(data) == 1 {
 								hit["data"] = data[0]
 							}
+						} else if readError == ctx.Err() {
+							return nil, readError
 						}
 					}
 				}
@@ -1140,6 +1371,12 @@ func (db *Database) readConfig() (bool, error) {
 		db.isPasswordProtected = val
 	}
 
+	// databases created before the key layout was introduced use plain ids
+	db.keyLayout = KeyLayoutId
+	if val, ok := jsonMap["keyLayout"].(string); ok && val != "" {
+		db.keyLayout = val
+	}
+
 	if db.isPasswordProtected {
 		v, e := db.isPasswordValid()
 		if !v {
@@ -1196,10 +1433,17 @@ func (db *Database) writeConfig() (bool, error) {
 		db.isPasswordProtected = true
 	}
 
+	return db.saveConfig()
+}
+
+// saveConfig writes the current configuration into dodod.json
+// without touching the password credentials
+func (db *Database) saveConfig() (bool, error) {
 	jsonMap := make(map[string]interface{})
 	jsonMap["encodedKey"] = db.encodedKey
 	jsonMap["isPasswordProtected"] = db.isPasswordProtected
 	jsonMap["indexStoreName"] = db.internalIndexStoreName
+	jsonMap["keyLayout"] = db.keyLayout
 
 	data, err := json.Marshal(jsonMap)
 	if err != nil {
@@ -1223,12 +1467,11 @@ func (db *Database) ensurePath() {
 	}
 }
 
-func (db *Database) openDb() error {
-	var index bleve.Index
-	var err error
-
+// openIndex opens or creates the index store at the path
+// using the configured index store name
+func (db *Database) openIndex(path string) (bleve.Index, error) {
 	if db.internalIndexStoreName == "badger" {
-		index, err = db.indexOpener.BleveIndex(db.dbPath,
+		return db.indexOpener.BleveIndex(path,
 			db.internalIndexMapping,
 			upsidedown.Name,
 			map[string]interface{}{
@@ -1238,19 +1481,28 @@ func (db *Database) openDb() error {
 					Logger:        DefaultLogger,
 				},
 			})
-	} else {
-		db.indexOpener.SetEngineName("boltdb")
-		index, err = db.indexOpener.BleveIndex(db.dbPath,
-			db.internalIndexMapping,
-			scorch.Name,
-			map[string]interface{}{
-				"ReadOnly": db.isReadOnly,
-				"BdodbConfig": &bdodb.Config{
-					EncryptionKey: db.secretKey,
-				},
-			})
 	}
 
+	db.indexOpener.SetEngineName("boltdb")
+	return db.indexOpener.BleveIndex(path,
+		db.internalIndexMapping,
+		scorch.Name,
+		map[string]interface{}{
+			"ReadOnly": db.isReadOnly,
+			"BdodbConfig": &bdodb.Config{
+				EncryptionKey: db.secretKey,
+			},
+		})
+}
+
+func (db *Database) openDb() error {
+	if !db.isReadOnly {
+		if err := db.recoverIndexSwap(); err != nil {
+			return err
+		}
+	}
+
+	index, err := db.openIndex(db.dbPath)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package dodod
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/blevesearch/bleve"
@@ -790,6 +791,7 @@ func TestDatabase_CreateIndex(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	defer func() { _ = db.Close() }()
 
 	if err := db.CreateIndex([]interface{}{&map[string]string{"id": "1"}}); err != ErrInvalidDocument {
 		t.Fatalf("unexpected error: %v", err)
@@ -833,6 +835,7 @@ func TestDatabase_UpdateIndex(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	defer func() { _ = db.Close() }()
 
 	if err := db.UpdateIndex([]interface{}{&map[string]string{"id": "1"}}); err != ErrInvalidDocument {
 		t.Fatalf("unexpected error: %v", err)
@@ -869,6 +872,7 @@ func TestDatabase_DeleteIndex(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	defer func() { _ = db.Close() }()
 
 	if err := db.DeleteIndex([]interface{}{&map[string]string{"id": "1"}}); err != ErrInvalidDocument {
 		t.Fatalf("unexpected error: %v", err)
@@ -882,6 +886,94 @@ func TestDatabase_DeleteIndex(t *testing.T) {
 	}
 }
 
+func TestDatabase_Context(t *testing.T) {
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.SearchContext(context.Background(), map[string]interface{}{}, ""); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	data := []interface{}{&MyTestDocument{Id: "1", Name: "Test1"}}
+
+	if err := db.CreateContext(cancelledCtx, data); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.IsDocumentExists("1") {
+		t.Fatalf("document should not be created with a cancelled context")
+	}
+
+	if err := db.CreateDocumentContext(cancelledCtx, data); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.CreateIndexContext(cancelledCtx, data); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.CreateContext(context.Background(), data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, err := db.ReadContext(cancelledCtx, []string{"1"}); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n, _, err := db.ReadContext(context.Background(), []string{"1"}); err != nil || n != 1 {
+		t.Fatalf("unexpected read result: %d, %v", n, err)
+	}
+
+	if _, err := db.GetDocumentContext(cancelledCtx, data); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, err := db.GetDocumentWithErrorContext(cancelledCtx, []string{"1"}); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.UpdateContext(cancelledCtx, data); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.DeleteContext(cancelledCtx, data); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !db.IsDocumentExists("1") {
+		t.Fatalf("document should not be deleted with a cancelled context")
+	}
+
+	if _, err := db.SearchContext(cancelledCtx, map[string]interface{}{}, "bleveSearchResult"); err == nil {
+		t.Fatalf("search should return an error with a cancelled context")
+	}
+
+	if data, err := db.SearchContext(context.Background(), map[string]interface{}{}, "bleveSearchResult"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if data.(*bleve.SearchResult).Total != 1 {
+		t.Fatalf("Total Expected 1, but found: %v", data.(*bleve.SearchResult).Total)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
+
 type CustomDocument struct {
 	Id           string `json:"id"`
 	CustomField1 string `json:"custom_field_1"`
@@ -0,0 +1,281 @@
+package dodod
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// ExportFilter selects the documents written by Export
+type ExportFilter struct {
+	// DocumentTypes limits the export to the document types,
+	// empty means every document type
+	DocumentTypes []string
+}
+
+// ExportResult reports the documents written by Export
+type ExportResult struct {
+	// Exported is the number of documents written
+	Exported uint64
+
+	// Skipped is the number of records which could not be decoded
+	Skipped uint64
+}
+
+// ImportOptions controls how Import loads the documents
+type ImportOptions struct {
+	// ChunkSize and ChunkBytes are passed to BulkWrite,
+	// ChunkSize is also the number of lines read before writing them
+	ChunkSize  int
+	ChunkBytes int
+
+	// SkipInvalid reports invalid lines and imports the valid ones,
+	// otherwise the import stops at the first invalid line
+	SkipInvalid bool
+
+	// AllowUnregistered imports the lines of document types which are not
+	// registered as raw documents the same way as PutRaw
+	AllowUnregistered bool
+}
+
+// ImportResult reports the documents loaded by Import
+type ImportResult struct {
+	// Imported is the number of documents written
+	Imported uint64
+
+	// Errors are the skipped invalid lines
+	Errors []*ImportLineError
+}
+
+// ImportLineError reports a line which could not be imported
+type ImportLineError struct {
+	// Line is the line number starting from one
+	Line int
+
+	Err error
+}
+
+func (e *ImportLineError) Error() string {
+	return fmt.Sprintf("dodod: import line %d: %v", e.Line, e.Err)
+}
+
+func (e *ImportLineError) Unwrap() error {
+	return e.Err
+}
+
+// exportRecord is a line of the export format
+type exportRecord struct {
+	Type string          `json:"type"`
+	Id   string          `json:"id"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Export writes the documents as JSON Lines, one
+// `{"type":...,"id":...,"data":...}` object per line in key order.
+// Documents of types which are not registered are written with their
+// stored json data, records which can not be decoded are skipped
+func (db *Database) Export(w io.Writer, filter *ExportFilter) (*ExportResult, error) {
+	return db.ExportContext(context.Background(), w, filter)
+}
+
+// ExportContext is the context aware variant of Export
+func (db *Database) ExportContext(ctx context.Context, w io.Writer, filter *ExportFilter) (*ExportResult, error) {
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	if filter == nil {
+		filter = &ExportFilter{}
+	}
+
+	types := make(map[string]bool)
+	for _, t := range filter.DocumentTypes {
+		types[t] = true
+	}
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	result := &ExportResult{}
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+
+	_, err := db.iterateRaw(ctx, txn, "", nil, func(key []byte, value []byte) error {
+		docType, err := documentTypeOf(value)
+		if err != nil {
+			result.Skipped = result.Skipped + 1
+			return nil
+		}
+
+		if len(types) > 0 && !types[docType] {
+			return nil
+		}
+
+		record, err := db.exportRecord(key, value)
+		if err != nil {
+			DefaultLogger.Warningf("key %s can not be exported: %v", string(key), err)
+			result.Skipped = result.Skipped + 1
+			return nil
+		}
+
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+
+		result.Exported = result.Exported + 1
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Import loads the JSON Lines written by Export through BulkWrite, existing
+// documents are replaced. Every line must name a registered document type,
+// unless AllowUnregistered is set, and carry data whose id matches the
+// line id, blank lines are ignored
+func (db *Database) Import(r io.Reader, opts *ImportOptions) (*ImportResult, error) {
+	return db.ImportContext(context.Background(), r, opts)
+}
+
+// ImportContext is the context aware variant of Import,
+// documents written before the context is done are kept
+func (db *Database) ImportContext(ctx context.Context, r io.Reader, opts *ImportOptions) (*ImportResult, error) {
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultBulkChunkSize
+	}
+
+	bulkOptions := &BulkOptions{ChunkSize: chunkSize, ChunkBytes: opts.ChunkBytes}
+	result := &ImportResult{Errors: make([]*ImportLineError, 0)}
+
+	pending := make([]interface{}, 0, chunkSize)
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+
+		bulkResult, err := db.BulkWriteContext(ctx, pending, bulkOptions)
+		if bulkResult != nil {
+			result.Imported = result.Imported + bulkResult.Written
+		}
+		pending = make([]interface{}, 0, chunkSize)
+		return err
+	}
+
+	reader := bufio.NewReader(r)
+	line := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		data, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return result, readErr
+		}
+
+		if len(data) > 0 {
+			line = line + 1
+		}
+
+		if len(bytes.TrimSpace(data)) > 0 {
+			doc, err := db.importRecord(data, opts.AllowUnregistered)
+			if err != nil {
+				lineError := &ImportLineError{Line: line, Err: err}
+				if !opts.SkipInvalid {
+					return result, lineError
+				}
+				result.Errors = append(result.Errors, lineError)
+			} else {
+				pending = append(pending, doc)
+			}
+		}
+
+		if len(pending) >= chunkSize || readErr == io.EOF {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+
+		if readErr == io.EOF {
+			return result, nil
+		}
+	}
+}
+
+// exportRecord returns the export line of the stored document
+func (db *Database) exportRecord(key []byte, value []byte) (*exportRecord, error) {
+	doc, err := db.DecodeDocument(value)
+	if err == ErrDocumentTypeIsNotRegistered {
+		raw, err := db.decodeRawDocument(string(key), value)
+		if err != nil {
+			return nil, err
+		}
+		return &exportRecord{Type: raw.Type, Id: raw.Id, Data: raw.Data}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	document := doc.(Document)
+	return &exportRecord{Type: document.Type(), Id: document.GetId(), Data: data}, nil
+}
+
+// importRecord decodes a line into a new document of its registered type,
+// or into a raw document when unregistered document types are allowed
+func (db *Database) importRecord(data []byte, allowUnregistered bool) (interface{}, error) {
+	record := &exportRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, ErrInvalidImportRecord
+	}
+
+	if record.Type == "" || record.Id == "" || len(record.Data) == 0 {
+		return nil, ErrInvalidImportRecord
+	}
+
+	registered, exists := db.documentRegistryCache[record.Type]
+	if !exists && allowUnregistered {
+		return newRawDocument(record.Type, record.Id, record.Data)
+	} else if !exists {
+		return nil, ErrDocumentTypeIsNotRegistered
+	}
+
+	doc := reflect.New(reflect.Indirect(reflect.ValueOf(registered)).Type()).Interface()
+	if err := json.Unmarshal(record.Data, doc); err != nil {
+		return nil, err
+	}
+
+	document, ok := doc.(Document)
+	if !ok {
+		return nil, ErrInvalidDocument
+	}
+
+	if document.Type() != record.Type || document.GetId() != record.Id {
+		return nil, ErrInvalidImportRecord
+	}
+
+	return doc, nil
+}
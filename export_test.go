@@ -0,0 +1,153 @@
+package dodod
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDatabase_ExportImport(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.RegisterDocument(&CustomDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.Export(&bytes.Buffer{}, nil); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&MyTestDocument{Id: "2", Name: "second"},
+		&CustomDocument{Id: "3"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := &bytes.Buffer{}
+	result, err := db.Export(output, &ExportFilter{DocumentTypes: []string{"MyTestDocument"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Exported != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	expected := `{"type":"MyTestDocument","id":"1","data":{"id":"1","name":"first"}}` + "\n" +
+		`{"type":"MyTestDocument","id":"2","data":{"id":"2","name":"second"}}` + "\n"
+	if output.String() != expected {
+		t.Fatalf("unexpected export: %s", output.String())
+	}
+
+	if err := db.Delete([]interface{}{&MyTestDocument{Id: "1"}, &MyTestDocument{Id: "2"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	imported, err := db.Import(bytes.NewReader(output.Bytes()), &ImportOptions{ChunkSize: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if imported.Imported != 2 {
+		t.Fatalf("unexpected result: %+v", imported)
+	}
+
+	if _, docs, _ := db.Read([]string{"2"}); len(docs) != 1 || docs[0].(*MyTestDocument).Name != "second" {
+		t.Fatalf("document should be imported")
+	}
+	if total, _ := searchTotal(t, db, "first"); total != 1 {
+		t.Fatalf("imported document should be indexed, found %d", total)
+	}
+
+	input := strings.Join([]string{
+		`{"type":"MyTestDocument","id":"4","data":{"id":"4","name":"fourth"}}`,
+		``,
+		`{"type":"Unknown","id":"5","data":{"id":"5"}}`,
+		`not json`,
+		`{"type":"MyTestDocument","id":"6","data":{"id":"7"}}`,
+		`{"type":"MyTestDocument","id":"8","data":{"id":"8"}}`,
+	}, "\n")
+
+	_, err = db.Import(strings.NewReader(input), nil)
+	var lineError *ImportLineError
+	if !errors.As(err, &lineError) || lineError.Line != 3 || !errors.Is(err, ErrDocumentTypeIsNotRegistered) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	imported, err = db.Import(strings.NewReader(input), &ImportOptions{SkipInvalid: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if imported.Imported != 2 || len(imported.Errors) != 3 {
+		t.Fatalf("unexpected result: %+v", imported)
+	}
+	if imported.Errors[1].Line != 4 || !errors.Is(imported.Errors[1], ErrInvalidImportRecord) {
+		t.Fatalf("unexpected line error: %v", imported.Errors[1])
+	}
+	if imported.Errors[2].Line != 5 || !errors.Is(imported.Errors[2], ErrInvalidImportRecord) {
+		t.Fatalf("unexpected line error: %v", imported.Errors[2])
+	}
+	if !db.IsDocumentExists("4") || !db.IsDocumentExists("8") || db.IsDocumentExists("7") {
+		t.Fatalf("valid lines should be imported")
+	}
+}
+
+func TestDatabase_ExportImportUnregistered(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.PutRaw("Note", "1", []byte(`{"title":"raw note"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := &bytes.Buffer{}
+	if result, err := db.Export(output, nil); err != nil || result.Exported != 1 {
+		t.Fatalf("unexpected result %+v: %v", result, err)
+	}
+	if output.String() != `{"type":"Note","id":"1","data":{"title":"raw note"}}`+"\n" {
+		t.Fatalf("unexpected export: %s", output.String())
+	}
+
+	input := `{"type":"Note","id":"2","data":{"title":"imported note"}}`
+	if _, err := db.Import(strings.NewReader(input), nil); !errors.Is(err, ErrDocumentTypeIsNotRegistered) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	imported, err := db.Import(strings.NewReader(input), &ImportOptions{AllowUnregistered: true})
+	if err != nil || imported.Imported != 1 {
+		t.Fatalf("unexpected result %+v: %v", imported, err)
+	}
+	if raw, err := db.ReadRaw("2"); err != nil || string(raw.Data) != `{"title":"imported note"}` {
+		t.Fatalf("unexpected raw document %+v: %v", raw, err)
+	}
+	if total, _ := searchTotal(t, db, "imported"); total != 1 {
+		t.Fatalf("imported raw document should be indexed, found %d", total)
+	}
+}
@@ -8,4 +8,7 @@ require (
 	github.com/go-openapi/inflect v0.19.0
 	github.com/mkawserm/bdodb v0.1.2
 	github.com/mkawserm/pasap v0.5.0
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	google.golang.org/grpc v1.43.0
+	google.golang.org/protobuf v1.27.1
 )
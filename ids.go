@@ -0,0 +1,272 @@
+package dodod
+
+import (
+	"context"
+	"github.com/dgraph-io/badger/v2"
+	"reflect"
+)
+
+// IdsResult tells which of the requested ids were found and which were
+// missing, both lists keep the order of the request
+type IdsResult struct {
+	Found   []string
+	Missing []string
+}
+
+func newIdsResult() *IdsResult {
+	return &IdsResult{Found: make([]string, 0), Missing: make([]string, 0)}
+}
+
+// idRecord is a stored record found for a requested id
+type idRecord struct {
+	id    string
+	key   string
+	value []byte
+}
+
+// idKey returns the key of the id, with an empty document type
+// the id is used as the key as is
+func (db *Database) idKey(docType string, id string) string {
+	if docType == "" {
+		return id
+	}
+	return db.DocumentKey(docType, id)
+}
+
+// findIds reads the records of the ids inside the transaction, an id holding
+// a document of another type than the document type is treated as missing
+func (db *Database) findIds(ctx context.Context, txn *badger.Txn, docType string, ids []string) ([]*idRecord, *IdsResult, error) {
+	records := make([]*idRecord, 0, len(ids))
+	result := newIdsResult()
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		if id == "" {
+			return nil, nil, ErrIdCanNotBeEmpty
+		}
+
+		key := db.idKey(docType, id)
+		item, err := txn.Get([]byte(key))
+		if err == badger.ErrKeyNotFound {
+			result.Missing = append(result.Missing, id)
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if docType != "" {
+			if storedType, err := documentTypeOf(value); err != nil || storedType != docType {
+				result.Missing = append(result.Missing, id)
+				continue
+			}
+		}
+
+		records = append(records, &idRecord{id: id, key: key, value: value})
+		result.Found = append(result.Found, id)
+	}
+
+	return records, result, nil
+}
+
+// ExistsMany checks which ids of the document type exist in the database,
+// an empty document type checks the ids as keys of any type
+func (db *Database) ExistsMany(docType string, ids []string) (*IdsResult, error) {
+	return db.ExistsManyContext(context.Background(), docType, ids)
+}
+
+// ExistsManyContext is the context aware variant of ExistsMany
+func (db *Database) ExistsManyContext(ctx context.Context, docType string, ids []string) (*IdsResult, error) {
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	_, result, err := db.findIds(ctx, txn, docType, ids)
+	return result, err
+}
+
+// ReadTyped reads the documents of the registered document type into out,
+// which must be a pointer to a slice of the registered document type.
+// Documents are appended in the order of the found ids
+func (db *Database) ReadTyped(docType string, ids []string, out interface{}) (*IdsResult, error) {
+	return db.ReadTypedContext(context.Background(), docType, ids, out)
+}
+
+// ReadTypedContext is the context aware variant of ReadTyped
+func (db *Database) ReadTypedContext(ctx context.Context, docType string, ids []string, out interface{}) (*IdsResult, error) {
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	registered, exists := db.documentRegistryCache[docType]
+	if !exists {
+		return nil, ErrDocumentTypeIsNotRegistered
+	}
+
+	outValue := reflect.ValueOf(out)
+	if outValue.Kind() != reflect.Ptr || outValue.Elem().Kind() != reflect.Slice ||
+		outValue.Elem().Type().Elem() != reflect.TypeOf(registered) {
+		return nil, ErrInvalidOutputType
+	}
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	records, result, err := db.findIds(ctx, txn, docType, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	slice := outValue.Elem()
+	for _, record := range records {
+		doc, err := db.DecodeDocument(record.value)
+		if err != nil {
+			return nil, err
+		}
+		slice = reflect.Append(slice, reflect.ValueOf(doc))
+	}
+	outValue.Elem().Set(slice)
+
+	return result, nil
+}
+
+// DeleteByIds deletes the documents of the document type from the database
+// and the index store, missing ids are reported and skipped
+func (db *Database) DeleteByIds(docType string, ids []string) (*IdsResult, error) {
+	return db.DeleteByIdsContext(context.Background(), docType, ids)
+}
+
+// DeleteByIdsContext is the context aware variant of DeleteByIds
+func (db *Database) DeleteByIdsContext(ctx context.Context, docType string, ids []string) (*IdsResult, error) {
+	return db.deleteByIds(ctx, docType, ids, true)
+}
+
+// DeleteDocumentByIds deletes the documents of the document type from
+// the database only, missing ids are reported and skipped
+func (db *Database) DeleteDocumentByIds(docType string, ids []string) (*IdsResult, error) {
+	return db.DeleteDocumentByIdsContext(context.Background(), docType, ids)
+}
+
+// DeleteDocumentByIdsContext is the context aware variant of DeleteDocumentByIds
+func (db *Database) DeleteDocumentByIdsContext(ctx context.Context, docType string, ids []string) (*IdsResult, error) {
+	return db.deleteByIds(ctx, docType, ids, false)
+}
+
+func (db *Database) deleteByIds(ctx context.Context, docType string, ids []string, withIndex bool) (*IdsResult, error) {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	internalBatchTxn := db.internalDb.NewTransaction(true)
+	defer internalBatchTxn.Discard()
+
+	records, result, err := db.findIds(ctx, internalBatchTxn, docType, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return result, nil
+	}
+
+	batch := db.internalIndex.NewBatch()
+	keys := make([]string, 0, len(records))
+	for _, record := range records {
+		if err := db.removeDocument(internalBatchTxn, record.key); err != nil {
+			return nil, err
+		}
+		batch.Delete(record.key)
+		keys = append(keys, record.key)
+	}
+
+	var intentKey []byte
+	if withIndex {
+		if intentKey, err = db.writeIndexIntent(internalBatchTxn, keys); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := internalBatchTxn.Commit(); err != nil {
+		return nil, commitError(err)
+	}
+
+	if !withIndex {
+		return result, nil
+	}
+
+	// the intent stays in the database on failure and
+	// will be replayed by ReplayIntentLog
+	if err := db.internalIndex.Batch(batch); err != nil {
+		return nil, ErrIndexStoreTransactionFailed
+	}
+
+	db.clearIndexIntent(intentKey)
+
+	return result, nil
+}
+
+// DeleteIndexByIds deletes the ids of the document type from the index store
+// only, ids which are not indexed are reported as missing
+func (db *Database) DeleteIndexByIds(docType string, ids []string) (*IdsResult, error) {
+	return db.DeleteIndexByIdsContext(context.Background(), docType, ids)
+}
+
+// DeleteIndexByIdsContext is the context aware variant of DeleteIndexByIds
+func (db *Database) DeleteIndexByIdsContext(ctx context.Context, docType string, ids []string) (*IdsResult, error) {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	result := newIdsResult()
+	batch := db.internalIndex.NewBatch()
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if id == "" {
+			return nil, ErrIdCanNotBeEmpty
+		}
+
+		key := db.idKey(docType, id)
+		if doc, err := db.internalIndex.Document(key); err != nil {
+			return nil, err
+		} else if doc == nil {
+			result.Missing = append(result.Missing, id)
+			continue
+		}
+
+		batch.Delete(key)
+		result.Found = append(result.Found, id)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := db.internalIndex.Batch(batch); err != nil {
+		return nil, ErrIndexStoreTransactionFailed
+	}
+
+	return result, nil
+}
@@ -0,0 +1,176 @@
+package dodod
+
+import (
+	"testing"
+)
+
+func TestDatabase_ExistsMany(t *testing.T) {
+	t.Helper()
+
+	for _, namespaced := range []bool{false, true} {
+		dbPath := "/tmp/dodod"
+
+		db := &Database{}
+		db.SetupDefaults()
+		db.SetDbPath(dbPath)
+		db.SetTypeNamespacedKeys(namespaced)
+
+		if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := db.RegisterDocument(&CustomDocument{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := db.ExistsMany("MyTestDocument", []string{"1"}); err != ErrDatabaseIsNotOpen {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := db.Open(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := db.Create([]interface{}{
+			&MyTestDocument{Id: "1"},
+			&MyTestDocument{Id: "3"},
+			&CustomDocument{Id: "2"},
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		result, err := db.ExistsMany("MyTestDocument", []string{"3", "2", "1", "4"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.Found) != 2 || result.Found[0] != "3" || result.Found[1] != "1" {
+			t.Fatalf("unexpected found ids: %v", result.Found)
+		}
+		if len(result.Missing) != 2 || result.Missing[0] != "2" || result.Missing[1] != "4" {
+			t.Fatalf("unexpected missing ids: %v", result.Missing)
+		}
+
+		if _, err := db.ExistsMany("MyTestDocument", []string{""}); err != ErrIdCanNotBeEmpty {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_ = db.Close()
+		cleanupDb(t, dbPath)
+	}
+}
+
+func TestDatabase_ReadTyped(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&MyTestDocument{Id: "2", Name: "second"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var docs []*MyTestDocument
+	result, err := db.ReadTyped("MyTestDocument", []string{"2", "5", "1"}, &docs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(docs) != 2 || docs[0].Name != "second" || docs[1].Name != "first" {
+		t.Fatalf("unexpected documents: %v", docs)
+	}
+	if len(result.Missing) != 1 || result.Missing[0] != "5" {
+		t.Fatalf("unexpected missing ids: %v", result.Missing)
+	}
+
+	if _, err := db.ReadTyped("Unknown", []string{"1"}, &docs); err != ErrDocumentTypeIsNotRegistered {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wrong []*CustomDocument
+	if _, err := db.ReadTyped("MyTestDocument", []string{"1"}, &wrong); err != ErrInvalidOutputType {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := db.ReadTyped("MyTestDocument", []string{"1"}, docs); err != ErrInvalidOutputType {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDatabase_DeleteByIds(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&MyTestDocument{Id: "2", Name: "second"},
+		&MyTestDocument{Id: "3", Name: "third"},
+		&MyTestDocument{Id: "4", Name: "fourth"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := db.DeleteByIds("MyTestDocument", []string{"1", "9"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Found) != 1 || result.Found[0] != "1" || len(result.Missing) != 1 || result.Missing[0] != "9" {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	if db.IsDocumentExists("1") || db.IsIndexExists("1") {
+		t.Fatalf("document should be deleted from both stores")
+	}
+
+	if _, err := db.DeleteDocumentByIds("MyTestDocument", []string{"2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.IsDocumentExists("2") || !db.IsIndexExists("2") {
+		t.Fatalf("document should be deleted from the database only")
+	}
+
+	result, err = db.DeleteIndexByIds("MyTestDocument", []string{"3", "2", "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Found) != 2 || len(result.Missing) != 1 || result.Missing[0] != "1" {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	if !db.IsDocumentExists("3") || db.IsIndexExists("3") || db.IsIndexExists("2") {
+		t.Fatalf("documents should be deleted from the index store only")
+	}
+
+	// an empty document type deletes the ids as keys
+	if _, err := db.DeleteByIds("", []string{"4"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.IsDocumentExists("4") {
+		t.Fatalf("document should be deleted")
+	}
+}
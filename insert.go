@@ -0,0 +1,43 @@
+package dodod
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DocumentAlreadyExistsError reports the ids which already exist
+// in the database when inserting documents
+type DocumentAlreadyExistsError struct {
+	Ids []string
+}
+
+func (e *DocumentAlreadyExistsError) Error() string {
+	return fmt.Sprintf("dodod: document already exists, ids: %s", strings.Join(e.Ids, ", "))
+}
+
+func (e *DocumentAlreadyExistsError) Unwrap() error {
+	return ErrDocumentAlreadyExists
+}
+
+// Insert stores and indexes the documents only if none of them exists,
+// otherwise nothing is written and a DocumentAlreadyExistsError naming
+// every existing id is returned
+func (db *Database) Insert(data []interface{}) error {
+	return db.InsertContext(context.Background(), data)
+}
+
+// InsertContext is the context aware variant of Insert
+func (db *Database) InsertContext(ctx context.Context, data []interface{}) error {
+	return db.createContext(ctx, data, true)
+}
+
+// Upsert stores and indexes the documents, existing documents are replaced
+func (db *Database) Upsert(data []interface{}) error {
+	return db.UpsertContext(context.Background(), data)
+}
+
+// UpsertContext is the context aware variant of Upsert
+func (db *Database) UpsertContext(ctx context.Context, data []interface{}) error {
+	return db.createContext(ctx, data, false)
+}
@@ -0,0 +1,99 @@
+package dodod
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDatabase_Insert(t *testing.T) {
+	t.Helper()
+
+	t.Run("Id key layout", func(t *testing.T) {
+		testInsert(t, false)
+	})
+
+	t.Run("Type key layout", func(t *testing.T) {
+		testInsert(t, true)
+	})
+}
+
+func testInsert(t *testing.T, typeNamespacedKeys bool) {
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+	db.SetTypeNamespacedKeys(typeNamespacedKeys)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Insert([]interface{}{&MyTestDocument{Id: "1"}}); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	key := func(id string) string {
+		return db.DocumentKey("MyTestDocument", id)
+	}
+
+	if err := db.Insert([]interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&MyTestDocument{Id: "2", Name: "second"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := db.Insert([]interface{}{
+		&MyTestDocument{Id: "1", Name: "changed"},
+		&MyTestDocument{Id: "3", Name: "third"},
+		&MyTestDocument{Id: "2", Name: "changed"},
+	})
+
+	var existsError *DocumentAlreadyExistsError
+	if !errors.As(err, &existsError) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(existsError.Ids) != 2 || existsError.Ids[0] != "1" || existsError.Ids[1] != "2" {
+		t.Fatalf("unexpected ids: %v", existsError.Ids)
+	}
+	if !errors.Is(err, ErrDocumentAlreadyExists) {
+		t.Fatalf("error should wrap ErrDocumentAlreadyExists")
+	}
+
+	if db.IsDocumentExists(key("3")) || db.IsIndexExists(key("3")) {
+		t.Fatalf("failed insert should not write any document")
+	}
+
+	if _, docs, _ := db.Read([]string{key("1")}); docs[0].(*MyTestDocument).Name != "first" {
+		t.Fatalf("failed insert should not replace documents")
+	}
+
+	// the same id twice inside one insert
+	if err := db.Insert([]interface{}{
+		&MyTestDocument{Id: "4"},
+		&MyTestDocument{Id: "4"},
+	}); !errors.Is(err, ErrDocumentAlreadyExists) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Upsert([]interface{}{
+		&MyTestDocument{Id: "1", Name: "changed"},
+		&MyTestDocument{Id: "3", Name: "third"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if total, docs, _ := db.Read([]string{key("1"), key("3")}); total != 2 || docs[0].(*MyTestDocument).Name != "changed" {
+		t.Fatalf("upsert should replace and create documents")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package dodod
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/dgraph-io/badger/v2"
+	"sync/atomic"
+	"time"
+)
+
+// internalKeyPrefix is reserved for dodod's own bookkeeping records
+// inside the badger database, document ids must not start with it
+var internalKeyPrefix = []byte("\x00dodod/")
+
+// intentLogPrefix holds pending index operations which are written
+// in the same badger transaction as the documents
+var intentLogPrefix = []byte("\x00dodod/intent/")
+
+var intentCounter uint64
+
+type indexIntent struct {
+	Ids []string `json:"ids"`
+}
+
+func isInternalKey(key []byte) bool {
+	return len(key) >= len(internalKeyPrefix) && string(key[:len(internalKeyPrefix)]) == string(internalKeyPrefix)
+}
+
+func newIntentKey() []byte {
+	key := make([]byte, len(intentLogPrefix)+16)
+	copy(key, intentLogPrefix)
+	binary.BigEndian.PutUint64(key[len(intentLogPrefix):], uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint64(key[len(intentLogPrefix)+8:], atomic.AddUint64(&intentCounter, 1))
+	return key
+}
+
+// intentWriter is implemented by badger transactions and write batches
+type intentWriter interface {
+	Set(key, value []byte) error
+}
+
+// writeIndexIntent records the ids whose index entries must follow
+// the badger state once the transaction is committed
+func (db *Database) writeIndexIntent(txn intentWriter, ids []string) ([]byte, error) {
+	data, err := json.Marshal(&indexIntent{Ids: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	key := newIntentKey()
+	if err := txn.Set(key, data); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// clearIndexIntent removes the intent after the index batch succeeded,
+// a failure here is harmless since replaying an intent is idempotent
+func (db *Database) clearIndexIntent(key []byte) {
+	err := db.internalDb.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+
+	if err != nil {
+		DefaultLogger.Warningf("failed to clear index intent: %v", err)
+	}
+}
+
+// ReplayIntentLog applies every pending index intent to the index store
+// so that the index converges to the documents stored in the database.
+//
+// For every recorded id the document is indexed if it exists in the
+// database, otherwise it is removed from the index. Documents which can
+// not be decoded are logged and left out of the index, their intents are
+// cleared as well since a later replay can not decode them either, use
+// Reindex to rebuild the index store once their types are registered.
+// It returns the number of replayed intents
+func (db *Database) ReplayIntentLog() (uint64, error) {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
+	if !db.IsDatabaseReady() {
+		return 0, ErrDatabaseIsNotOpen
+	}
+
+	if db.isReadOnly {
+		return 0, nil
+	}
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = intentLogPrefix
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	batch := db.internalIndex.NewBatch()
+	replayed := make([][]byte, 0)
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return 0, err
+		}
+
+		intent := &indexIntent{}
+		if err := json.Unmarshal(value, intent); err != nil {
+			DefaultLogger.Warningf("dropping malformed index intent: %v", err)
+			replayed = append(replayed, item.KeyCopy(nil))
+			continue
+		}
+
+		for _, id := range intent.Ids {
+			docItem, err := txn.Get([]byte(id))
+			if err == badger.ErrKeyNotFound {
+				batch.Delete(id)
+				continue
+			} else if err != nil {
+				return 0, err
+			}
+
+			docValue, err := docItem.ValueCopy(nil)
+			if err != nil {
+				return 0, err
+			}
+
+			doc, err := db.decodeIndexValue(id, docValue)
+			if err != nil {
+				DefaultLogger.Warningf("index intent for id %s can not be replayed: %v", id, err)
+				continue
+			}
+
+			if err := batch.Index(id, doc); err != nil {
+				return 0, err
+			}
+		}
+
+		replayed = append(replayed, item.KeyCopy(nil))
+	}
+
+	if len(replayed) == 0 {
+		return 0, nil
+	}
+
+	if err := db.internalIndex.Batch(batch); err != nil {
+		return 0, ErrIndexStoreTransactionFailed
+	}
+
+	for _, key := range replayed {
+		db.clearIndexIntent(key)
+	}
+
+	return uint64(len(replayed)), nil
+}
@@ -0,0 +1,164 @@
+package dodod
+
+import (
+	"github.com/dgraph-io/badger/v2"
+	"testing"
+)
+
+func countIndexIntents(t *testing.T, db *Database) int {
+	t.Helper()
+
+	count := 0
+	err := db.internalDb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = intentLogPrefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			count = count + 1
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return count
+}
+
+func TestDatabase_IntentLog(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	openDb := func() *Database {
+		db := &Database{}
+		db.SetupDefaults()
+		db.SetDbPath(dbPath)
+		if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := db.Open(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return db
+	}
+
+	db := &Database{}
+	if _, err := db.ReplayIntentLog(); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	db = openDb()
+
+	if err := db.Create([]interface{}{&MyTestDocument{Id: "1", Name: "Test1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := countIndexIntents(t, db); n != 0 {
+		t.Fatalf("intent log should be empty, found: %d", n)
+	}
+
+	// Simulate a crash between the database commit and the index batch
+	err := db.internalDb.Update(func(txn *badger.Txn) error {
+		d := &MyTestDocument{Id: "2", Name: "Test2"}
+		data, err := db.EncodeDocument(d)
+		if err != nil {
+			return err
+		}
+		if err := txn.Set([]byte(d.Id), data); err != nil {
+			return err
+		}
+		if err := txn.Delete([]byte("1")); err != nil {
+			return err
+		}
+		_, err = db.writeIndexIntent(txn, []string{"1", "2"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.IsIndexExists("2") {
+		t.Fatalf("index should not exist before replay")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+
+	db = openDb()
+
+	if n := countIndexIntents(t, db); n != 0 {
+		t.Fatalf("intent log should be empty after replay, found: %d", n)
+	}
+
+	if !db.IsIndexExists("2") {
+		t.Fatalf("index should exist after replay")
+	}
+
+	if db.IsIndexExists("1") {
+		t.Fatalf("index should be removed after replay")
+	}
+
+	if n, err := db.ReplayIntentLog(); err != nil || n != 0 {
+		t.Fatalf("unexpected replay result: %d, %v", n, err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
+
+func TestDatabase_IntentLogUndecodable(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// an intent of a record which is not an encoded document
+	err := db.internalDb.Update(func(txn *badger.Txn) error {
+		if err := txn.Set([]byte("1"), []byte("invalid")); err != nil {
+			return err
+		}
+		d := &MyTestDocument{Id: "2", Name: "Test2"}
+		data, err := db.EncodeDocument(d)
+		if err != nil {
+			return err
+		}
+		if err := txn.Set([]byte(d.Id), data); err != nil {
+			return err
+		}
+		_, err = db.writeIndexIntent(txn, []string{"1", "2"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n, err := db.ReplayIntentLog(); err != nil || n != 1 {
+		t.Fatalf("unexpected replay result: %d, %v", n, err)
+	}
+
+	if n := countIndexIntents(t, db); n != 0 {
+		t.Fatalf("undecodable intent should be cleared, found: %d", n)
+	}
+
+	if !db.IsIndexExists("2") || db.IsIndexExists("1") {
+		t.Fatalf("only the decodable document should be indexed")
+	}
+
+	_ = db.Close()
+}
@@ -0,0 +1,182 @@
+package dodod
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/dgraph-io/badger/v2"
+)
+
+// errSkipRecord is returned by the callback of iterateRaw
+// for a record which does not count as visited
+var errSkipRecord = errors.New("dodod: skip record")
+
+// IterateOptions controls the documents visited by Iterate and List
+type IterateOptions struct {
+	// Prefix limits the iteration to ids starting with it, when the document
+	// type is empty and type namespaced keys are enabled it is a key prefix
+	Prefix string
+
+	// Reverse visits the documents in descending key order
+	Reverse bool
+
+	// Limit is the maximum number of documents visited, zero means no limit
+	Limit int
+
+	// Cursor resumes the iteration after the document which produced it
+	Cursor string
+}
+
+// Iterate streams the decoded documents of the document type stored in
+// the database in key order, an empty document type visits every document.
+//
+// The callback receives the document key and the decoded document, an error
+// returned by the callback stops the iteration and is returned as is.
+// Records which can not be decoded, such as documents of unregistered
+// types, are logged and skipped.
+// The returned cursor resumes the iteration with the same options once the
+// limit was reached, it is empty when there are no more documents
+func (db *Database) Iterate(docType string, opts *IterateOptions, fn func(key string, doc interface{}) error) (string, error) {
+	return db.IterateContext(context.Background(), docType, opts, fn)
+}
+
+// IterateContext is the context aware variant of Iterate
+func (db *Database) IterateContext(ctx context.Context,
+	docType string,
+	opts *IterateOptions,
+	fn func(key string, doc interface{}) error) (string, error) {
+
+	if !db.IsDatabaseReady() {
+		return "", ErrDatabaseIsNotOpen
+	}
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	return db.iterateRaw(ctx, txn, docType, opts, func(key []byte, value []byte) error {
+		doc, err := db.DecodeDocument(value)
+		if err != nil {
+			DefaultLogger.Warningf("skipping document %s which can not be decoded: %v", string(key), err)
+			return errSkipRecord
+		}
+		return fn(string(key), doc)
+	})
+}
+
+// List reads a page of documents of the document type stored in the database,
+// the returned cursor is passed with the same options to read the next page
+func (db *Database) List(docType string, opts *IterateOptions) ([]interface{}, string, error) {
+	return db.ListContext(context.Background(), docType, opts)
+}
+
+// ListContext is the context aware variant of List
+func (db *Database) ListContext(ctx context.Context, docType string, opts *IterateOptions) ([]interface{}, string, error) {
+	output := make([]interface{}, 0)
+	cursor, err := db.IterateContext(ctx, docType, opts, func(_ string, doc interface{}) error {
+		output = append(output, doc)
+		return nil
+	})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return output, cursor, nil
+}
+
+// iteratePrefix returns the key prefix covering the document type and id prefix
+func (db *Database) iteratePrefix(docType string, idPrefix string) []byte {
+	if docType != "" && db.keyLayout == KeyLayoutType {
+		return []byte(db.DocumentKey(docType, idPrefix))
+	}
+	return []byte(idPrefix)
+}
+
+// iterateRaw visits the stored records of the document type inside the
+// transaction without decoding them, internal records are skipped
+func (db *Database) iterateRaw(ctx context.Context,
+	txn *badger.Txn,
+	docType string,
+	opts *IterateOptions,
+	fn func(key []byte, value []byte) error) (string, error) {
+
+	if opts == nil {
+		opts = &IterateOptions{}
+	}
+
+	if opts.Limit < 0 {
+		return "", ErrInvalidIterateOptions
+	}
+
+	prefix := db.iteratePrefix(docType, opts.Prefix)
+
+	var cursorKey []byte
+	if opts.Cursor != "" {
+		var err error
+		cursorKey, err = base64.RawURLEncoding.DecodeString(opts.Cursor)
+		if err != nil || !bytes.HasPrefix(cursorKey, prefix) {
+			return "", ErrInvalidCursor
+		}
+	}
+
+	iteratorOptions := badger.DefaultIteratorOptions
+	iteratorOptions.Reverse = opts.Reverse
+	iteratorOptions.Prefix = prefix
+	it := txn.NewIterator(iteratorOptions)
+	defer it.Close()
+
+	switch {
+	case cursorKey != nil:
+		it.Seek(cursorKey)
+		if it.Valid() && bytes.Equal(it.Item().Key(), cursorKey) {
+			it.Next()
+		}
+	case opts.Reverse && len(prefix) > 0:
+		// a reverse seek lands on the largest key not greater than the seek key
+		it.Seek(append(append([]byte{}, prefix...), 0xFF, 0xFF, 0xFF, 0xFF))
+	default:
+		it.Rewind()
+	}
+
+	visited := 0
+	var lastKey []byte
+
+	for ; it.Valid(); it.Next() {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		item := it.Item()
+		if isInternalKey(item.Key()) {
+			continue
+		}
+
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return "", err
+		}
+
+		if docType != "" {
+			if storedType, err := documentTypeOf(value); err != nil || storedType != docType {
+				continue
+			}
+		}
+
+		// another document exists after the limit so the cursor is useful
+		if opts.Limit > 0 && visited == opts.Limit {
+			return base64.RawURLEncoding.EncodeToString(lastKey), nil
+		}
+
+		lastKey = item.KeyCopy(nil)
+		if err := fn(lastKey, value); err == errSkipRecord {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		visited = visited + 1
+	}
+
+	return "", nil
+}
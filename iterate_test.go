@@ -0,0 +1,123 @@
+package dodod
+
+import (
+	"testing"
+)
+
+func listIds(t *testing.T, db *Database, docType string, opts *IterateOptions) ([]string, string) {
+	t.Helper()
+
+	docs, cursor, err := db.List(docType, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.(Document).GetId())
+	}
+	return ids, cursor
+}
+
+func TestDatabase_Iterate(t *testing.T) {
+	t.Helper()
+
+	for _, namespaced := range []bool{false, true} {
+		func() {
+			dbPath := "/tmp/dodod"
+			defer cleanupDb(t, dbPath)
+
+			db := &Database{}
+			db.SetupDefaults()
+			db.SetDbPath(dbPath)
+			db.SetTypeNamespacedKeys(namespaced)
+
+			if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := db.RegisterDocument(&RangeTestDocument{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, _, err := db.List("MyTestDocument", nil); err != ErrDatabaseIsNotOpen {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := db.Open(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := db.Create([]interface{}{
+				&MyTestDocument{Id: "a1"},
+				&MyTestDocument{Id: "a2"},
+				&MyTestDocument{Id: "a3"},
+				&MyTestDocument{Id: "b1"},
+				&MyTestDocument{Id: "b2"},
+				&RangeTestDocument{Id: "r1"},
+			}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ids, cursor := listIds(t, db, "MyTestDocument", nil); len(ids) != 5 || cursor != "" {
+				t.Fatalf("unexpected ids: %v, cursor: %s", ids, cursor)
+			}
+
+			if ids, _ := listIds(t, db, "", nil); len(ids) != 6 {
+				t.Fatalf("unexpected ids: %v", ids)
+			}
+
+			t.Run("Pages", func(t *testing.T) {
+				opts := &IterateOptions{Limit: 2}
+				pages := make([][]string, 0)
+				for {
+					ids, cursor := listIds(t, db, "MyTestDocument", opts)
+					pages = append(pages, ids)
+					if cursor == "" {
+						break
+					}
+					opts.Cursor = cursor
+				}
+
+				if len(pages) != 3 || pages[0][0] != "a1" || pages[1][0] != "a3" || pages[2][0] != "b2" {
+					t.Fatalf("unexpected pages: %v", pages)
+				}
+			})
+
+			t.Run("Reverse with prefix", func(t *testing.T) {
+				ids, cursor := listIds(t, db, "MyTestDocument", &IterateOptions{Prefix: "a", Reverse: true, Limit: 2})
+				if len(ids) != 2 || ids[0] != "a3" || ids[1] != "a2" {
+					t.Fatalf("unexpected ids: %v", ids)
+				}
+
+				ids, cursor = listIds(t, db, "MyTestDocument", &IterateOptions{Prefix: "a", Reverse: true, Limit: 2, Cursor: cursor})
+				if len(ids) != 1 || ids[0] != "a1" || cursor != "" {
+					t.Fatalf("unexpected ids: %v, cursor: %s", ids, cursor)
+				}
+			})
+
+			t.Run("Undecodable records are skipped", func(t *testing.T) {
+				if err := db.PutRaw("Unregistered", "a0", []byte(`{"id":"a0"}`)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if ids, _ := listIds(t, db, "", nil); len(ids) != 6 {
+					t.Fatalf("unexpected ids: %v", ids)
+				}
+
+				if ids, _ := listIds(t, db, "", &IterateOptions{Limit: 1}); len(ids) != 1 || ids[0] != "a1" {
+					t.Fatalf("unexpected ids: %v", ids)
+				}
+			})
+
+			t.Run("Invalid cursor", func(t *testing.T) {
+				if _, _, err := db.List("MyTestDocument", &IterateOptions{Cursor: "%%"}); err != ErrInvalidCursor {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			})
+
+			if err := db.Close(); err != nil {
+				t.Fatalf("error occured while closing, error: %v", err)
+			}
+		}()
+	}
+}
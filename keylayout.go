@@ -0,0 +1,443 @@
+package dodod
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/blevesearch/bleve"
+	"github.com/dgraph-io/badger/v2"
+	"strings"
+)
+
+// KeyLayoutId stores every document under its id, documents of
+// different types sharing an id overwrite each other
+const KeyLayoutId = "id"
+
+// KeyLayoutType stores every document under `<type>:<id>` so that
+// different document types can share ids, `\` and `:` are escaped
+// with a `\` inside the type
+const KeyLayoutType = "type"
+
+// keyLayoutMigrationChunkSize is the number of documents moved
+// inside a single transaction by the key layout migration
+const keyLayoutMigrationChunkSize = 1000
+
+// keyLayoutMigrationPrefix holds the plain id keys which are not
+// migrated yet and the marker of a started migration
+var keyLayoutMigrationPrefix = []byte("\x00dodod/migration/")
+
+var keyLayoutMigrationStartedKey = append(append([]byte{}, keyLayoutMigrationPrefix...), "started"...)
+
+var keyLayoutMigrationPendingPrefix = append(append([]byte{}, keyLayoutMigrationPrefix...), "pending/"...)
+
+// keyTypeEscaper escapes the separator inside the document type,
+// so that the type can not end inside the id
+var keyTypeEscaper = strings.NewReplacer(`\`, `\\`, `:`, `\:`)
+
+// SetTypeNamespacedKeys opts into the type namespaced key layout.
+// A new database records the layout in dodod.json, an existing database
+// using plain ids is migrated once by Open
+func (db *Database) SetTypeNamespacedKeys(b bool) {
+	db.typeNamespacedKeys = b
+}
+
+// GetKeyLayout returns the key layout of the opened database
+func (db *Database) GetKeyLayout() string {
+	return db.keyLayout
+}
+
+// DocumentKey returns the key used by the database and the index store
+// for the document type and id, the same key is reported as the id of
+// search hits and accepted by Read
+func (db *Database) DocumentKey(docType string, id string) string {
+	if db.keyLayout == KeyLayoutType {
+		return TypeNamespacedKey(docType, id)
+	}
+	return id
+}
+
+// TypeNamespacedKey returns the key of the document type and id
+// in the KeyLayoutType key layout
+func TypeNamespacedKey(docType string, id string) string {
+	return keyTypeEscaper.Replace(docType) + ":" + id
+}
+
+// splitTypeNamespacedKey returns the document type and id of a key
+// built by TypeNamespacedKey
+func splitTypeNamespacedKey(key string) (string, string, bool) {
+	docType := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '\\':
+			if i+1 < len(key) {
+				i = i + 1
+				docType = append(docType, key[i])
+			}
+		case ':':
+			return string(docType), key[i+1:], true
+		default:
+			docType = append(docType, key[i])
+		}
+	}
+	return "", "", false
+}
+
+func keyLayoutMigrationPendingKey(key []byte) []byte {
+	return append(append([]byte{}, keyLayoutMigrationPendingPrefix...), key...)
+}
+
+func (db *Database) documentKey(document Document) string {
+	return db.DocumentKey(document.Type(), document.GetId())
+}
+
+// documentTypeOf returns the document type stored in the envelope
+// without decoding the document
+func documentTypeOf(data []byte) (string, error) {
+	if len(data) < 8 {
+		return "", ErrInvalidData
+	}
+
+	documentTypeLength := binary.BigEndian.Uint32(data[0:4])
+	if len(data) < (8 + int(documentTypeLength)) {
+		return "", ErrInvalidData
+	}
+
+	return string(data[4 : 4+documentTypeLength]), nil
+}
+
+// ReadWithType reads the documents of the document type using their ids,
+// ids holding a document of another type are skipped
+func (db *Database) ReadWithType(docType string, ids []string) (uint64, []interface{}, error) {
+	return db.ReadWithTypeContext(context.Background(), docType, ids)
+}
+
+// ReadWithTypeContext is the context aware variant of ReadWithType
+func (db *Database) ReadWithTypeContext(ctx context.Context, docType string, ids []string) (uint64, []interface{}, error) {
+	if !db.IsDatabaseReady() {
+		return 0, nil, ErrDatabaseIsNotOpen
+	}
+
+	internalBatchTxn := db.internalDb.NewTransaction(false)
+	defer internalBatchTxn.Discard()
+
+	output := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+
+		if id == "" {
+			continue
+		}
+
+		item, err := internalBatchTxn.Get([]byte(db.DocumentKey(docType, id)))
+		if err != nil {
+			continue
+		}
+
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			continue
+		}
+
+		if storedType, err := documentTypeOf(value); err != nil || storedType != docType {
+			continue
+		}
+
+		if doc, err := db.DecodeDocument(value); err == nil {
+			output = append(output, doc)
+		}
+	}
+
+	return uint64(len(output)), output, nil
+}
+
+// IsDocumentExistsWithType checks if a document of the document type
+// is stored under the id
+func (db *Database) IsDocumentExistsWithType(docType string, id string) bool {
+	if !db.IsDatabaseReady() {
+		return false
+	}
+
+	internalBatchTxn := db.internalDb.NewTransaction(false)
+	defer internalBatchTxn.Discard()
+
+	item, err := internalBatchTxn.Get([]byte(db.DocumentKey(docType, id)))
+	if err != nil {
+		return false
+	}
+
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		return false
+	}
+
+	storedType, err := documentTypeOf(value)
+	return err == nil && storedType == docType
+}
+
+// MigrateToTypeNamespacedKeys moves every document stored under a plain
+// id to `<type>:<id>` in the database and the index store, then records
+// the new key layout in dodod.json.
+//
+// The plain id keys are recorded before anything is moved and every
+// chunk of moved documents goes through the intent log, so an interrupted
+// migration is resumed by calling it again or by the next Open.
+// It returns the number of moved documents
+func (db *Database) MigrateToTypeNamespacedKeys() (uint64, error) {
+	if !db.IsDatabaseReady() {
+		return 0, ErrDatabaseIsNotOpen
+	}
+
+	if db.isReadOnly {
+		return 0, ErrDatabaseIsReadOnly
+	}
+
+	if db.keyLayout == KeyLayoutType {
+		return 0, nil
+	}
+
+	started, err := db.isKeyLayoutMigrationStarted()
+	if err != nil {
+		return 0, err
+	}
+
+	if !started {
+		if err := db.startKeyLayoutMigration(); err != nil {
+			return 0, err
+		}
+	}
+
+	var migrated uint64
+	for {
+		keys, err := db.pendingMigrationKeys(keyLayoutMigrationChunkSize)
+		if err != nil {
+			return migrated, err
+		}
+
+		if len(keys) == 0 {
+			break
+		}
+
+		n, err := db.migrateKeys(keys)
+		if err != nil {
+			return migrated, err
+		}
+		migrated = migrated + n
+	}
+
+	// the marker is removed once the layout is recorded, otherwise
+	// a resumed migration would move the migrated keys again
+	db.keyLayout = KeyLayoutType
+	if _, err := db.saveConfig(); err != nil {
+		db.keyLayout = KeyLayoutId
+		return migrated, err
+	}
+
+	if err := db.internalDb.Update(func(txn *badger.Txn) error {
+		return txn.Delete(keyLayoutMigrationStartedKey)
+	}); err != nil {
+		return migrated, err
+	}
+
+	return migrated, nil
+}
+
+// isKeyLayoutMigrationStarted reports if a key layout migration was
+// started and is not finished yet
+func (db *Database) isKeyLayoutMigrationStarted() (bool, error) {
+	started := false
+	err := db.internalDb.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(keyLayoutMigrationStartedKey)
+		if err == nil {
+			started = true
+			return nil
+		} else if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		return err
+	})
+
+	return started, err
+}
+
+// startKeyLayoutMigration records every plain id key as pending, the
+// migration is marked as started once all of them are recorded
+func (db *Database) startKeyLayoutMigration() error {
+	keys := make([][]byte, 0)
+	err := db.internalDb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := it.Item().KeyCopy(nil)
+			if !isInternalKey(key) {
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(keys); start += keyLayoutMigrationChunkSize {
+		end := start + keyLayoutMigrationChunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		err := db.internalDb.Update(func(txn *badger.Txn) error {
+			for _, key := range keys[start:end] {
+				if err := txn.Set(keyLayoutMigrationPendingKey(key), []byte{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.internalDb.Update(func(txn *badger.Txn) error {
+		return txn.Set(keyLayoutMigrationStartedKey, []byte{})
+	})
+}
+
+// pendingMigrationKeys returns up to n plain id keys which are not migrated yet
+func (db *Database) pendingMigrationKeys(n int) ([][]byte, error) {
+	keys := make([][]byte, 0, n)
+	err := db.internalDb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = keyLayoutMigrationPendingPrefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid() && len(keys) < n; it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil)[len(keyLayoutMigrationPendingPrefix):])
+		}
+		return nil
+	})
+
+	return keys, err
+}
+
+func (db *Database) migrateKeys(keys [][]byte) (uint64, error) {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
+	internalBatchTxn := db.internalDb.NewTransaction(true)
+	defer internalBatchTxn.Discard()
+
+	batch := db.internalIndex.NewBatch()
+	ids := make([]string, 0, 2*len(keys))
+	var migrated uint64
+
+	for _, key := range keys {
+		n, err := db.migrateKey(internalBatchTxn, batch, string(key), &ids)
+		if err != nil {
+			return 0, err
+		}
+		migrated = migrated + n
+	}
+
+	if len(ids) > 0 {
+		intentKey, err := db.writeIndexIntent(internalBatchTxn, ids)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := internalBatchTxn.Commit(); err != nil {
+			return 0, ErrDatabaseTransactionFailed
+		}
+
+		if err := db.internalIndex.Batch(batch); err != nil {
+			return 0, ErrIndexStoreTransactionFailed
+		}
+
+		db.clearIndexIntent(intentKey)
+	} else if err := internalBatchTxn.Commit(); err != nil {
+		return 0, ErrDatabaseTransactionFailed
+	}
+
+	return migrated, nil
+}
+
+// migrateKey moves the document of the pending plain id key, a pending
+// key holding the target key is moved first. It returns the number of
+// moved documents
+func (db *Database) migrateKey(txn *badger.Txn, batch *bleve.Batch, oldKey string, ids *[]string) (uint64, error) {
+	pendingKey := keyLayoutMigrationPendingKey([]byte(oldKey))
+	if _, err := txn.Get(pendingKey); err == badger.ErrKeyNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if err := txn.Delete(pendingKey); err != nil {
+		return 0, err
+	}
+
+	item, err := txn.Get([]byte(oldKey))
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	docType, err := documentTypeOf(value)
+	if err != nil {
+		DefaultLogger.Warningf("key %s can not be migrated: %v", oldKey, err)
+		return 0, nil
+	}
+
+	newKey := TypeNamespacedKey(docType, oldKey)
+
+	// the target may still hold a document stored under a plain id
+	var migrated uint64
+	n, err := db.migrateKey(txn, batch, newKey, ids)
+	if err != nil {
+		return 0, err
+	}
+	migrated = migrated + n
+
+	if err := db.removeDocument(txn, oldKey); err != nil {
+		return 0, err
+	}
+	batch.Delete(oldKey)
+
+	// documents of unregistered types are moved as they are
+	// and indexed by their json fields
+	if doc, err := db.DecodeDocument(value); err == nil {
+		version, _ := db.DecodeDocumentVersion(value)
+		if err := db.putDocumentWithVersion(txn, newKey, doc, version); err != nil {
+			return 0, err
+		}
+		if err := batch.Index(newKey, indexValue(doc)); err != nil {
+			return 0, err
+		}
+		*ids = append(*ids, newKey)
+	} else {
+		if err := txn.Set([]byte(newKey), value); err != nil {
+			return 0, err
+		}
+		if raw, err := db.decodeIndexValue(newKey, value); err == nil {
+			if err := batch.Index(newKey, raw); err != nil {
+				return 0, err
+			}
+			*ids = append(*ids, newKey)
+		}
+	}
+
+	*ids = append(*ids, oldKey)
+
+	return migrated + 1, nil
+}
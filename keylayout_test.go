@@ -0,0 +1,231 @@
+package dodod
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestDatabase_TypeNamespacedKeys(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+	db.SetTypeNamespacedKeys(true)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.RegisterDocument(&RangeTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.GetKeyLayout() != KeyLayoutType {
+		t.Fatalf("unexpected key layout: %s", db.GetKeyLayout())
+	}
+
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&RangeTestDocument{Id: "1", Code: "apple"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if total, docs, err := db.ReadWithType("MyTestDocument", []string{"1"}); err != nil || total != 1 {
+		t.Fatalf("expected 1 document, found: %d, error: %v", total, err)
+	} else if docs[0].(*MyTestDocument).Name != "first" {
+		t.Fatalf("unexpected document: %v", docs[0])
+	}
+
+	if total, docs, err := db.ReadWithType("RangeTestDocument", []string{"1"}); err != nil || total != 1 {
+		t.Fatalf("expected 1 document, found: %d, error: %v", total, err)
+	} else if docs[0].(*RangeTestDocument).Code != "apple" {
+		t.Fatalf("unexpected document: %v", docs[0])
+	}
+
+	if !db.IsIndexExists("MyTestDocument:1") || !db.IsIndexExists("RangeTestDocument:1") {
+		t.Fatalf("both documents should be indexed")
+	}
+
+	if db.DocumentKey("a", "b:c") == db.DocumentKey("a:b", "c") {
+		t.Fatalf("keys of different types should not collide: %s", db.DocumentKey("a", "b:c"))
+	}
+
+	if docType, id, ok := splitTypeNamespacedKey(db.DocumentKey(`a:b\`, "c")); !ok || docType != `a:b\` || id != "c" {
+		t.Fatalf("unexpected document type: %s, id: %s", docType, id)
+	}
+
+	if !db.IsDocumentExistsWithType("MyTestDocument", "1") {
+		t.Fatalf("document should exist")
+	}
+
+	if err := db.Delete([]interface{}{&MyTestDocument{Id: "1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.IsDocumentExistsWithType("MyTestDocument", "1") || !db.IsDocumentExistsWithType("RangeTestDocument", "1") {
+		t.Fatalf("only MyTestDocument should be deleted")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+
+	data, _ := ioutil.ReadFile(dbPath + "/dodod.json")
+	if !strings.Contains(string(data), `"keyLayout":"type"`) {
+		t.Fatalf("key layout is not recorded: %s", string(data))
+	}
+}
+
+func TestDatabase_MigrateToTypeNamespacedKeys(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.GetKeyLayout() != KeyLayoutId {
+		t.Fatalf("unexpected key layout: %s", db.GetKeyLayout())
+	}
+
+	// the plain id `MyTestDocument:1` is the migrated key of `1`
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&MyTestDocument{Id: "2", Name: "second"},
+		&MyTestDocument{Id: "MyTestDocument:1", Name: "legacy"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+
+	db = &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+	db.SetTypeNamespacedKeys(true)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.GetKeyLayout() != KeyLayoutType {
+		t.Fatalf("unexpected key layout: %s", db.GetKeyLayout())
+	}
+
+	if total, _, _ := db.Read([]string{"1", "2"}); total != 0 {
+		t.Fatalf("plain ids should be moved, found: %d", total)
+	}
+
+	if total, _, _ := db.ReadWithType("MyTestDocument", []string{"1", "2"}); total != 2 {
+		t.Fatalf("expected 2 documents, found: %d", total)
+	}
+
+	if total, docs, _ := db.ReadWithType("MyTestDocument", []string{"1", "MyTestDocument:1"}); total != 2 {
+		t.Fatalf("expected 2 documents, found: %d", total)
+	} else if docs[0].(*MyTestDocument).Name != "first" || docs[1].(*MyTestDocument).Name != "legacy" {
+		t.Fatalf("unexpected documents: %v, %v", docs[0], docs[1])
+	}
+
+	if db.IsIndexExists("1") || !db.IsIndexExists("MyTestDocument:1") {
+		t.Fatalf("index entries should be moved")
+	}
+
+	if n, err := db.MigrateToTypeNamespacedKeys(); err != nil || n != 0 {
+		t.Fatalf("second migration should be a no-op, found: %d, error: %v", n, err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
+
+func TestDatabase_MigrateToTypeNamespacedKeysResume(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&MyTestDocument{Id: "2", Name: "second"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// interrupt the migration after the first document is moved
+	if err := db.startKeyLayoutMigration(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, err := db.migrateKeys([][]byte{[]byte("1")}); err != nil || n != 1 {
+		t.Fatalf("expected 1 moved document, found: %d, error: %v", n, err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+
+	db = &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.GetKeyLayout() != KeyLayoutType {
+		t.Fatalf("interrupted migration should be resumed, key layout: %s", db.GetKeyLayout())
+	}
+
+	if total, _, _ := db.ReadWithType("MyTestDocument", []string{"1", "2"}); total != 2 {
+		t.Fatalf("expected 2 documents, found: %d", total)
+	}
+
+	if total, _, _ := db.Read([]string{"MyTestDocument:MyTestDocument:1"}); total != 0 {
+		t.Fatalf("moved document should not be moved again")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
@@ -0,0 +1,498 @@
+package dodod
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dgraph-io/badger/v2"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PatchError reports the patch operation or path which can not be applied
+type PatchError struct {
+	Path   string
+	Reason string
+}
+
+func (e *PatchError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("dodod: invalid patch, %s", e.Reason)
+	}
+	return fmt.Sprintf("dodod: invalid patch at `%s`, %s", e.Path, e.Reason)
+}
+
+func (e *PatchError) Unwrap() error {
+	return ErrInvalidPatch
+}
+
+// Patch applies a JSON merge patch (RFC 7386) to the document stored under
+// the key inside one transaction, a null value removes the member.
+//
+// The result must decode into the registered document type without unknown
+// fields and keep its id, it is stored with the next version and reindexed.
+// It returns the patched document
+func (db *Database) Patch(key string, patch map[string]interface{}) (interface{}, error) {
+	return db.PatchContext(context.Background(), key, patch)
+}
+
+// PatchContext is the context aware variant of Patch
+func (db *Database) PatchContext(ctx context.Context, key string, patch map[string]interface{}) (interface{}, error) {
+	normalized, err := normalizeJSON(patch)
+	if err != nil {
+		return nil, &PatchError{Reason: err.Error()}
+	}
+
+	return db.patchDocument(ctx, key, func(target interface{}) (interface{}, error) {
+		return mergePatch(target, normalized), nil
+	})
+}
+
+// JSONPatch applies a JSON patch (RFC 6902) to the document stored under the
+// key inside one transaction. Every operation is a map of op, path, value and
+// from, the supported ops are add, remove, replace, move, copy and test.
+//
+// The result is validated, stored and reindexed the same way as Patch
+func (db *Database) JSONPatch(key string, operations []map[string]interface{}) (interface{}, error) {
+	return db.JSONPatchContext(context.Background(), key, operations)
+}
+
+// JSONPatchContext is the context aware variant of JSONPatch
+func (db *Database) JSONPatchContext(ctx context.Context, key string, operations []map[string]interface{}) (interface{}, error) {
+	return db.patchDocument(ctx, key, func(target interface{}) (interface{}, error) {
+		return applyJSONPatch(target, operations)
+	})
+}
+
+// patchDocument replaces the document stored under the key with the
+// result of apply inside one transaction
+func (db *Database) patchDocument(ctx context.Context, key string, apply func(target interface{}) (interface{}, error)) (interface{}, error) {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	if key == "" {
+		return nil, ErrIdCanNotBeEmpty
+	}
+
+	internalBatchTxn := db.internalDb.NewTransaction(true)
+	defer internalBatchTxn.Discard()
+
+	item, err := internalBatchTxn.Get([]byte(key))
+	if err == badger.ErrKeyNotFound {
+		return nil, ErrDocumentNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := db.DecodeDocument(value)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := normalizeJSON(current)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := apply(target)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := db.validatePatchedDocument(current.(Document), patched)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.putDocument(internalBatchTxn, key, doc); err != nil {
+		return nil, err
+	}
+
+	batch := db.internalIndex.NewBatch()
+	if err := batch.Index(key, indexValue(doc)); err != nil {
+		return nil, err
+	}
+
+	intentKey, err := db.writeIndexIntent(internalBatchTxn, []string{key})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := internalBatchTxn.Commit(); err != nil {
+		return nil, commitError(err)
+	}
+
+	// the intent stays in the database on failure and
+	// will be replayed by ReplayIntentLog
+	if err := db.internalIndex.Batch(batch); err != nil {
+		return nil, ErrIndexStoreTransactionFailed
+	}
+
+	db.clearIndexIntent(intentKey)
+
+	return doc, nil
+}
+
+// validatePatchedDocument decodes the patched json into a new document of
+// the registered type of the current document, unknown fields, type
+// mismatches and id changes are rejected
+func (db *Database) validatePatchedDocument(current Document, patched interface{}) (interface{}, error) {
+	if _, ok := patched.(map[string]interface{}); !ok {
+		return nil, &PatchError{Reason: "patched document must be an object"}
+	}
+
+	registered, exists := db.documentRegistryCache[current.Type()]
+	if !exists {
+		return nil, ErrDocumentTypeIsNotRegistered
+	}
+
+	data, err := json.Marshal(patched)
+	if err != nil {
+		return nil, &PatchError{Reason: err.Error()}
+	}
+
+	doc := reflect.New(reflect.Indirect(reflect.ValueOf(registered)).Type()).Interface()
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(doc); err != nil {
+		return nil, &PatchError{Reason: err.Error()}
+	}
+
+	document, ok := doc.(Document)
+	if !ok {
+		return nil, ErrInvalidDocument
+	}
+
+	if document.GetId() != current.GetId() {
+		return nil, &PatchError{Reason: "the id of the document can not be changed"}
+	}
+
+	return doc, nil
+}
+
+// normalizeJSON converts the value into the generic json representation
+func normalizeJSON(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var output interface{}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// mergePatch applies the merge patch to the target as defined by RFC 7386
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap = make(map[string]interface{})
+	}
+
+	for k, v := range patchMap {
+		if v == nil {
+			delete(targetMap, k)
+		} else {
+			targetMap[k] = mergePatch(targetMap[k], v)
+		}
+	}
+
+	return targetMap
+}
+
+// applyJSONPatch applies the operations to the document as defined by RFC 6902,
+// the operations are applied in order and the first failure aborts the patch
+func applyJSONPatch(doc interface{}, operations []map[string]interface{}) (interface{}, error) {
+	for i, operation := range operations {
+		at := fmt.Sprintf("operations[%d]", i)
+
+		op, _ := operation["op"].(string)
+
+		path, ok := operation["path"].(string)
+		if !ok {
+			return nil, &PatchError{Path: at, Reason: "path must be a string"}
+		}
+
+		tokens, err := parsePointer(path)
+		if err != nil {
+			return nil, err
+		}
+
+		from := func() ([]string, error) {
+			fromPath, ok := operation["from"].(string)
+			if !ok {
+				return nil, &PatchError{Path: at, Reason: "from must be a string"}
+			}
+			return parsePointer(fromPath)
+		}
+
+		value := func() (interface{}, error) {
+			v, found := operation["value"]
+			if !found {
+				return nil, &PatchError{Path: at, Reason: "value is required"}
+			}
+			return normalizeJSON(v)
+		}
+
+		switch op {
+		case "add":
+			var v interface{}
+			if v, err = value(); err == nil {
+				doc, err = pointerAdd(doc, tokens, v)
+			}
+
+		case "remove":
+			doc, _, err = pointerRemove(doc, tokens)
+
+		case "replace":
+			var v interface{}
+			if v, err = value(); err == nil {
+				if len(tokens) == 0 {
+					// the root is replaced as a whole
+					doc = v
+				} else if doc, _, err = pointerRemove(doc, tokens); err == nil {
+					doc, err = pointerAdd(doc, tokens, v)
+				}
+			}
+
+		case "move":
+			var fromTokens []string
+			var moved interface{}
+			if fromTokens, err = from(); err == nil {
+				if pointerString(fromTokens) == pointerString(tokens) {
+					// moving a value onto itself leaves the document as is
+					_, err = pointerGet(doc, fromTokens)
+				} else if isPointerPrefix(fromTokens, tokens) {
+					err = &PatchError{Path: path, Reason: "a value can not be moved into one of its children"}
+				} else if doc, moved, err = pointerRemove(doc, fromTokens); err == nil {
+					doc, err = pointerAdd(doc, tokens, moved)
+				}
+			}
+
+		case "copy":
+			var fromTokens []string
+			var copied interface{}
+			if fromTokens, err = from(); err == nil {
+				if copied, err = pointerGet(doc, fromTokens); err == nil {
+					if copied, err = normalizeJSON(copied); err == nil {
+						doc, err = pointerAdd(doc, tokens, copied)
+					}
+				}
+			}
+
+		case "test":
+			var v interface{}
+			var actual interface{}
+			if v, err = value(); err == nil {
+				if actual, err = pointerGet(doc, tokens); err == nil && !reflect.DeepEqual(actual, v) {
+					err = &PatchError{Path: path, Reason: "test failed"}
+				}
+			}
+
+		default:
+			err = &PatchError{Path: at, Reason: fmt.Sprintf("unknown op `%s`", op)}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// parsePointer splits a JSON pointer (RFC 6901) into its reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, &PatchError{Path: pointer, Reason: "pointer must start with /"}
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	return tokens, nil
+}
+
+func pointerString(tokens []string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	escaped := make([]string, len(tokens))
+	for i, token := range tokens {
+		escaped[i] = strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+	}
+
+	return "/" + strings.Join(escaped, "/")
+}
+
+// isPointerPrefix reports whether the prefix tokens reference
+// a parent of the location referenced by the tokens
+func isPointerPrefix(prefix []string, tokens []string) bool {
+	if len(prefix) >= len(tokens) {
+		return false
+	}
+
+	for i, token := range prefix {
+		if tokens[i] != token {
+			return false
+		}
+	}
+
+	return true
+}
+
+func arrayIndex(array []interface{}, token string, allowEnd bool) (int, bool) {
+	if allowEnd && token == "-" {
+		return len(array), true
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > len(array) || (!allowEnd && index == len(array)) {
+		return 0, false
+	}
+
+	return index, true
+}
+
+// pointerGet returns the value referenced by the tokens
+func pointerGet(doc interface{}, tokens []string) (interface{}, error) {
+	current := doc
+	for i, token := range tokens {
+		switch container := current.(type) {
+		case map[string]interface{}:
+			v, found := container[token]
+			if !found {
+				return nil, &PatchError{Path: pointerString(tokens[:i+1]), Reason: "path does not exist"}
+			}
+			current = v
+		case []interface{}:
+			index, ok := arrayIndex(container, token, false)
+			if !ok {
+				return nil, &PatchError{Path: pointerString(tokens[:i+1]), Reason: "invalid array index"}
+			}
+			current = container[index]
+		default:
+			return nil, &PatchError{Path: pointerString(tokens[:i+1]), Reason: "path does not exist"}
+		}
+	}
+
+	return current, nil
+}
+
+// pointerUpdate replaces the parent container of the last token with the
+// result of fn and returns the new document
+func pointerUpdate(doc interface{}, tokens []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+
+	child, err := pointerGet(doc, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	newChild, err := pointerUpdate(child, tokens[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		container[tokens[0]] = newChild
+	case []interface{}:
+		index, _ := arrayIndex(container, tokens[0], false)
+		container[index] = newChild
+	}
+
+	return doc, nil
+}
+
+// pointerAdd adds the value at the location referenced by the tokens
+func pointerAdd(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return pointerUpdate(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			index, ok := arrayIndex(container, token, true)
+			if !ok {
+				return nil, &PatchError{Path: pointerString(tokens), Reason: "invalid array index"}
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		default:
+			return nil, &PatchError{Path: pointerString(tokens), Reason: "parent is not a container"}
+		}
+	})
+}
+
+// pointerRemove removes the value referenced by the tokens
+// and returns the new document along with the removed value
+func pointerRemove(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, nil, &PatchError{Reason: "the whole document can not be removed"}
+	}
+
+	var removed interface{}
+	output, err := pointerUpdate(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			v, found := container[token]
+			if !found {
+				return nil, &PatchError{Path: pointerString(tokens), Reason: "path does not exist"}
+			}
+			removed = v
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, ok := arrayIndex(container, token, false)
+			if !ok {
+				return nil, &PatchError{Path: pointerString(tokens), Reason: "invalid array index"}
+			}
+			removed = container[index]
+			return append(container[:index], container[index+1:]...), nil
+		default:
+			return nil, &PatchError{Path: pointerString(tokens), Reason: "path does not exist"}
+		}
+	})
+
+	return output, removed, err
+}
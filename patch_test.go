@@ -0,0 +1,197 @@
+package dodod
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	t.Helper()
+
+	doc := map[string]interface{}{
+		"name": "test",
+		"tags": []interface{}{"a", "b"},
+		"meta": map[string]interface{}{"a/b": 1.0},
+	}
+
+	output, err := applyJSONPatch(doc, []map[string]interface{}{
+		{"op": "add", "path": "/tags/-", "value": "c"},
+		{"op": "add", "path": "/tags/0", "value": "z"},
+		{"op": "remove", "path": "/tags/1"},
+		{"op": "replace", "path": "/name", "value": "replaced"},
+		{"op": "copy", "from": "/name", "path": "/copy"},
+		{"op": "move", "from": "/meta/a~1b", "path": "/moved"},
+		{"op": "test", "path": "/moved", "value": 1},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"name":  "replaced",
+		"copy":  "replaced",
+		"tags":  []interface{}{"z", "b", "c"},
+		"meta":  map[string]interface{}{},
+		"moved": 1.0,
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("unexpected output: %v", output)
+	}
+
+	t.Run("Root", func(t *testing.T) {
+		testCases := []struct {
+			operation map[string]interface{}
+			expected  interface{}
+		}{
+			{
+				operation: map[string]interface{}{"op": "replace", "path": "", "value": map[string]interface{}{"name": "root"}},
+				expected:  map[string]interface{}{"name": "root"},
+			},
+			{
+				operation: map[string]interface{}{"op": "move", "from": "/meta", "path": ""},
+				expected:  map[string]interface{}{"a": 1.0},
+			},
+			{
+				operation: map[string]interface{}{"op": "copy", "from": "/meta", "path": ""},
+				expected:  map[string]interface{}{"a": 1.0},
+			},
+			{
+				operation: map[string]interface{}{"op": "move", "from": "", "path": ""},
+				expected:  map[string]interface{}{"name": "test", "meta": map[string]interface{}{"a": 1.0}},
+			},
+		}
+
+		for _, testCase := range testCases {
+			doc := map[string]interface{}{"name": "test", "meta": map[string]interface{}{"a": 1.0}}
+			output, err := applyJSONPatch(doc, []map[string]interface{}{testCase.operation})
+			if err != nil {
+				t.Fatalf("unexpected error for %v: %v", testCase.operation, err)
+			}
+			if !reflect.DeepEqual(output, testCase.expected) {
+				t.Fatalf("unexpected output for %v: %v", testCase.operation, output)
+			}
+		}
+	})
+
+	testCases := []map[string]interface{}{
+		{"op": "move", "from": "/tags", "path": "/tags/0"},
+		{"op": "move", "from": "", "path": "/name"},
+		{"op": "remove", "path": "/missing"},
+		{"op": "add", "path": "/tags/5", "value": "x"},
+		{"op": "test", "path": "/name", "value": "other"},
+		{"op": "replace", "path": "name", "value": "x"},
+		{"op": "unknown", "path": "/name"},
+		{"op": "add", "path": "/name"},
+	}
+	for _, testCase := range testCases {
+		doc := map[string]interface{}{"name": "test", "tags": []interface{}{}}
+		if _, err := applyJSONPatch(doc, []map[string]interface{}{testCase}); !errors.Is(err, ErrInvalidPatch) {
+			t.Fatalf("expected invalid patch for %v, found: %v", testCase, err)
+		}
+	}
+}
+
+func TestDatabase_Patch(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&RangeTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.Patch("1", map[string]interface{}{}); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{&RangeTestDocument{Id: "1", Code: "apple", Price: 10}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	doc, err := db.Patch("1", map[string]interface{}{"code": "banana", "price": nil})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if patched := doc.(*RangeTestDocument); patched.Code != "banana" || patched.Price != 0 {
+		t.Fatalf("unexpected document: %+v", patched)
+	}
+
+	if _, docs, _ := db.Read([]string{"1"}); docs[0].(*RangeTestDocument).Code != "banana" {
+		t.Fatalf("patch is not stored")
+	}
+
+	if version, _ := db.GetVersion("1"); version != 2 {
+		t.Fatalf("expected version 2, found: %d", version)
+	}
+
+	if total, _ := searchTotal(t, db, "banana"); total != 1 {
+		t.Fatalf("patched document should be reindexed")
+	}
+
+	t.Run("Invalid patches", func(t *testing.T) {
+		for _, patch := range []map[string]interface{}{
+			{"unknown": "value"},
+			{"price": "not a number"},
+			{"id": "2"},
+		} {
+			if _, err := db.Patch("1", patch); !errors.Is(err, ErrInvalidPatch) {
+				t.Fatalf("expected invalid patch for %v, found: %v", patch, err)
+			}
+		}
+
+		if _, err := db.Patch("missing", map[string]interface{}{"code": "x"}); err != ErrDocumentNotFound {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("JSON patch", func(t *testing.T) {
+		doc, err := db.JSONPatch("1", []map[string]interface{}{
+			{"op": "test", "path": "/code", "value": "banana"},
+			{"op": "replace", "path": "/code", "value": "cherry"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if doc.(*RangeTestDocument).Code != "cherry" {
+			t.Fatalf("unexpected document: %+v", doc)
+		}
+
+		if _, err := db.JSONPatch("1", []map[string]interface{}{
+			{"op": "test", "path": "/code", "value": "banana"},
+		}); !errors.Is(err, ErrInvalidPatch) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
+
+func searchTotal(t *testing.T, db *Database, term string) (uint64, error) {
+	t.Helper()
+
+	request, err := NewSearchRequestBuilder().
+		QueryMap(map[string]interface{}{"name": "Match", "p": map[string]interface{}{"match": term}}).
+		Build()
+	if err != nil {
+		return 0, err
+	}
+
+	output, err := db.SearchWithRequest(request, "map")
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(output.(map[string]interface{})["total_hits"].(float64)), nil
+}
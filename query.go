@@ -2,7 +2,10 @@ package dodod
 
 // Query interface defines all query related methods
 type Query interface {
-	// Read data using the provided id
+	// Read data using the provided id, the id is the document key
+	// when type namespaced keys are enabled. Documents which can not
+	// be decoded, such as documents of a type which is not registered,
+	// are skipped and not counted
 	Read(data []string) (uint64, []interface{}, error)
 
 	// GetDocument will fill up the data provided by the interface
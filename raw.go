@@ -0,0 +1,196 @@
+package dodod
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/dgraph-io/badger/v2"
+	"reflect"
+	"strings"
+)
+
+// RawDocument is a stored document read without decoding it,
+// its document type does not have to be registered
+type RawDocument struct {
+	Key     string
+	Type    string
+	Id      string
+	Version uint64
+	Data    json.RawMessage
+}
+
+// rawDocument is a document of a type which is not registered, it is
+// stored as is and indexed by the default mapping of the index store
+type rawDocument struct {
+	docType string
+	id      string
+	data    json.RawMessage
+	fields  map[string]interface{}
+}
+
+func (r *rawDocument) Type() string {
+	return r.docType
+}
+
+func (r *rawDocument) GetId() string {
+	return r.id
+}
+
+func (r *rawDocument) MarshalJSON() ([]byte, error) {
+	return r.data, nil
+}
+
+// newRawDocument validates the json data which must be an object
+func newRawDocument(docType string, id string, data []byte) (*rawDocument, error) {
+	compacted := &bytes.Buffer{}
+	if err := json.Compact(compacted, data); err != nil {
+		return nil, ErrInvalidDocument
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(compacted.Bytes(), &fields); err != nil {
+		return nil, ErrInvalidDocument
+	}
+
+	return &rawDocument{docType: docType, id: id, data: compacted.Bytes(), fields: fields}, nil
+}
+
+// indexValue returns the value passed to the index store for the document
+func indexValue(d interface{}) interface{} {
+	if raw, ok := d.(*rawDocument); ok {
+		return raw.fields
+	}
+	return d
+}
+
+// decodeIndexValue decodes the stored document into the value passed to the
+// index store, a document of a type which is not registered is indexed by
+// its json fields the same way as PutRaw
+func (db *Database) decodeIndexValue(key string, value []byte) (interface{}, error) {
+	doc, err := db.DecodeDocument(value)
+	if err != ErrDocumentTypeIsNotRegistered {
+		return doc, err
+	}
+
+	stored, err := db.decodeRawDocument(key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := newRawDocument(stored.Type, stored.Id, stored.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexValue(raw), nil
+}
+
+// rawId returns the id of the document stored under the key
+func (db *Database) rawId(key string, docType string) string {
+	if db.keyLayout == KeyLayoutType {
+		return strings.TrimPrefix(key, db.DocumentKey(docType, ""))
+	}
+	return key
+}
+
+// decodeRawDocument splits an encoded document into its parts
+func (db *Database) decodeRawDocument(key string, value []byte) (*RawDocument, error) {
+	docType, err := documentTypeOf(value)
+	if err != nil {
+		return nil, err
+	}
+
+	data, version := envelopeData(value, binary.BigEndian.Uint32(value[0:4]))
+
+	return &RawDocument{
+		Key:     key,
+		Type:    docType,
+		Id:      db.rawId(key, docType),
+		Version: version,
+		Data:    data,
+	}, nil
+}
+
+// ReadRaw reads the document stored under the key without decoding it
+func (db *Database) ReadRaw(key string) (*RawDocument, error) {
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	if key == "" {
+		return nil, ErrIdCanNotBeEmpty
+	}
+
+	if isInternalKey([]byte(key)) {
+		return nil, ErrIdIsReserved
+	}
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	item, err := txn.Get([]byte(key))
+	if err == badger.ErrKeyNotFound {
+		return nil, ErrDocumentNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.decodeRawDocument(key, value)
+}
+
+// PutRaw stores and indexes the json object as the document of the type
+// and id, an existing document is replaced. A registered document type is
+// decoded into its registered document first, the json data of any other
+// type is stored as is and indexed by the default mapping
+func (db *Database) PutRaw(docType string, id string, data []byte) error {
+	return db.PutRawContext(context.Background(), docType, id, data)
+}
+
+// PutRawContext is the context aware variant of PutRaw
+func (db *Database) PutRawContext(ctx context.Context, docType string, id string, data []byte) error {
+	if !db.IsDatabaseReady() {
+		return ErrDatabaseIsNotOpen
+	}
+
+	doc, err := db.rawToDocument(docType, id, data)
+	if err != nil {
+		return err
+	}
+
+	return db.UpdateContext(ctx, []interface{}{doc})
+}
+
+// rawToDocument turns the json data into a document of the type,
+// the id of a registered document must match the id
+func (db *Database) rawToDocument(docType string, id string, data []byte) (interface{}, error) {
+	if docType == "" {
+		return nil, ErrInvalidDocument
+	}
+
+	if id == "" {
+		return nil, ErrIdCanNotBeEmpty
+	}
+
+	registered, exists := db.documentRegistryCache[docType]
+	if !exists {
+		return newRawDocument(docType, id, data)
+	}
+
+	doc := reflect.New(reflect.Indirect(reflect.ValueOf(registered)).Type()).Interface()
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, ErrInvalidDocument
+	}
+
+	document, ok := doc.(Document)
+	if !ok || document.GetId() != id {
+		return nil, ErrInvalidDocument
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,113 @@
+package dodod
+
+import (
+	"testing"
+)
+
+func TestDatabase_PutRawReadRaw(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+	db.SetTypeNamespacedKeys(true)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.PutRaw("Note", "1", []byte(`{}`)); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.PutRaw("Note", "1", []byte(`{"title": "raw note"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.PutRaw("Note", "1", []byte(`{"title": "raw notes"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	raw, err := db.ReadRaw("Note:1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if raw.Type != "Note" || raw.Id != "1" || raw.Version != 2 || string(raw.Data) != `{"title":"raw notes"}` {
+		t.Fatalf("unexpected raw document: %+v", raw)
+	}
+
+	if total, _ := searchTotal(t, db, "notes"); total != 1 {
+		t.Fatalf("raw document should be indexed, found %d", total)
+	}
+
+	if err := db.PutRaw("MyTestDocument", "2", []byte(`{"id":"2","name":"typed"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, docs, _ := db.ReadWithType("MyTestDocument", []string{"2"}); len(docs) != 1 || docs[0].(*MyTestDocument).Name != "typed" {
+		t.Fatalf("registered document should be decoded")
+	}
+
+	if err := db.PutRaw("MyTestDocument", "3", []byte(`{"id":"4"}`)); err != ErrInvalidDocument {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.PutRaw("Note", "5", []byte(`[1, 2]`)); err != ErrInvalidDocument {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.PutRaw("Note", "", []byte(`{}`)); err != ErrIdCanNotBeEmpty {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.ReadRaw("Note:5"); err != ErrDocumentNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDatabase_RawDocumentIndex(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+	db.SetTypeNamespacedKeys(true)
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.PutRaw("Note", "1", []byte(`{"title": "raw notes"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.Reindex(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total, _ := searchTotal(t, db, "notes"); total != 1 {
+		t.Fatalf("raw document should be reindexed, found %d", total)
+	}
+
+	if _, err := db.DeleteIndexByIds("Note", []string{"1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, err := db.Verify(&VerifyOptions{Repair: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.UndecodableIds) != 0 || len(report.UnindexedIds) != 1 || !report.Repaired {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if total, _ := searchTotal(t, db, "notes"); total != 1 {
+		t.Fatalf("raw document should be repaired, found %d", total)
+	}
+}
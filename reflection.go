@@ -34,6 +34,59 @@ func extractFields(t reflect.Type, data map[string]string) {
 	}
 }
 
+// secondaryIndexField is a document field declared as
+// secondary index using the dodod struct tag
+//
+//	Email string `json:"email" dodod:"unique"`
+//	City  string `json:"city" dodod:"index"`
+type secondaryIndexField struct {
+	name   string
+	index  []int
+	unique bool
+}
+
+func extractSecondaryIndexFields(document interface{}) []*secondaryIndexField {
+	t := reflect.TypeOf(document)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []*secondaryIndexField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tags := strings.Split(f.Tag.Get("dodod"), ",")
+
+		isIndex := false
+		isUnique := false
+		for _, tag := range tags {
+			switch strings.TrimSpace(tag) {
+			case "index":
+				isIndex = true
+			case "unique":
+				isUnique = true
+			}
+		}
+
+		if !isIndex && !isUnique {
+			continue
+		}
+
+		name := f.Name
+		jsonTags := strings.Split(f.Tag.Get("json"), ",")
+		if jsonName := strings.TrimSpace(jsonTags[0]); jsonName != "" && jsonName != "-" {
+			name = jsonName
+		}
+
+		fields = append(fields, &secondaryIndexField{name: name, index: f.Index, unique: isUnique})
+	}
+
+	return fields
+}
+
 func GetId(document interface{}) string {
 	return getId(reflect.TypeOf(document), reflect.ValueOf(document))
 }
@@ -0,0 +1,325 @@
+package dodod
+
+import (
+	"context"
+	"github.com/dgraph-io/badger/v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// reindexStagingDir holds the index built by Reindex until it is swapped in
+const reindexStagingDir = "reindex"
+
+// reindexOldDir holds the previous index while it is swapped out
+const reindexOldDir = "reindex.old"
+
+// reindexCompleteMarker is written into the staging directory once the
+// staging index is complete, only a complete index is swapped in
+const reindexCompleteMarker = "complete"
+
+// DefaultReindexBatchSize is the number of documents indexed per batch
+const DefaultReindexBatchSize = 1000
+
+// indexEntries are the files and directories of the index store
+// inside the database path
+var indexEntries = []string{"index_meta.json", "store"}
+
+// ReindexOptions controls the index rebuild done by Reindex
+type ReindexOptions struct {
+	// BatchSize is the number of documents indexed per batch,
+	// zero means DefaultReindexBatchSize
+	BatchSize int
+
+	// Progress is called after every batch
+	Progress func(progress *ReindexProgress)
+}
+
+// ReindexProgress reports the documents processed by Reindex
+type ReindexProgress struct {
+	// Indexed is the number of documents written into the new index
+	Indexed uint64
+
+	// Skipped is the number of records which could not be decoded
+	Skipped uint64
+}
+
+// Reindex builds a fresh index store from the documents stored in the
+// database using the current index mapping and swaps it in place of the
+// current index store.
+//
+// The new index is built next to the current one from a snapshot of the
+// database, documents written while it is built are applied after the swap.
+// An interrupted swap is completed by the next Open
+func (db *Database) Reindex(opts *ReindexOptions) (*ReindexProgress, error) {
+	return db.ReindexContext(context.Background(), opts)
+}
+
+// ReindexContext is the context aware variant of Reindex,
+// the partially built index is discarded once the context is done
+func (db *Database) ReindexContext(ctx context.Context, opts *ReindexOptions) (*ReindexProgress, error) {
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	if db.isReadOnly {
+		return nil, ErrDatabaseIsReadOnly
+	}
+
+	if opts == nil {
+		opts = &ReindexOptions{}
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultReindexBatchSize
+	}
+
+	stagingPath := filepath.Join(db.dbPath, reindexStagingDir)
+	if err := os.RemoveAll(stagingPath); err != nil {
+		return nil, err
+	}
+
+	progress, since, err := db.buildIndex(ctx, stagingPath, batchSize, opts.Progress)
+	if err != nil {
+		_ = os.RemoveAll(stagingPath)
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(stagingPath, reindexCompleteMarker), []byte{}, 0700); err != nil {
+		_ = os.RemoveAll(stagingPath)
+		return nil, err
+	}
+
+	// writers are blocked until the new index caught up with them
+	db.indexLock.Lock()
+	defer db.indexLock.Unlock()
+
+	_ = db.internalIndex.Close()
+
+	if err := db.recoverIndexSwap(); err != nil {
+		db.closeAfterFailedSwap()
+		return nil, err
+	}
+
+	index, err := db.openIndex(db.dbPath)
+	if err != nil {
+		db.closeAfterFailedSwap()
+		return nil, err
+	}
+	db.internalIndex = index
+
+	if err := db.catchUpIndex(since); err != nil {
+		return progress, err
+	}
+
+	return progress, nil
+}
+
+// closeAfterFailedSwap closes the database once the index store could not be
+// swapped, the caller holds the index lock. A complete staging index is kept
+// so that the next Open finishes the swap
+func (db *Database) closeAfterFailedSwap() {
+	db.isDbReady = false
+	db.internalIndex = nil
+
+	if db.internalDb != nil {
+		_ = db.internalDb.Close()
+		db.internalDb = nil
+	}
+}
+
+// buildIndex writes every document of a database snapshot into a new
+// index store at the path, it returns the highest document version seen
+func (db *Database) buildIndex(ctx context.Context,
+	path string,
+	batchSize int,
+	progressFunc func(progress *ReindexProgress)) (*ReindexProgress, uint64, error) {
+
+	index, err := db.openIndex(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = index.Close()
+	}()
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	// values are read one by one, prefetching goroutines of skipped internal
+	// records would outlive the iterator and block the database close
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	progress := &ReindexProgress{}
+	var since uint64
+	batch := index.NewBatch()
+
+	flush := func() error {
+		if batch.Size() == 0 {
+			return nil
+		}
+		if err := index.Batch(batch); err != nil {
+			return ErrIndexStoreTransactionFailed
+		}
+		batch.Reset()
+		if progressFunc != nil {
+			progressFunc(&ReindexProgress{Indexed: progress.Indexed, Skipped: progress.Skipped})
+		}
+		return nil
+	}
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+
+		item := it.Item()
+		if isInternalKey(item.Key()) {
+			continue
+		}
+
+		if item.Version() > since {
+			since = item.Version()
+		}
+
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		doc, err := db.decodeIndexValue(string(item.Key()), value)
+		if err != nil {
+			DefaultLogger.Warningf("key %s can not be reindexed: %v", string(item.Key()), err)
+			progress.Skipped = progress.Skipped + 1
+			continue
+		}
+
+		if err := batch.Index(string(item.Key()), doc); err != nil {
+			return nil, 0, err
+		}
+		progress.Indexed = progress.Indexed + 1
+
+		if batch.Size() >= batchSize {
+			if err := flush(); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, 0, err
+	}
+
+	return progress, since, nil
+}
+
+// catchUpIndex applies the documents written or deleted after
+// the version to the index store
+func (db *Database) catchUpIndex(since uint64) error {
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.AllVersions = true
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+
+	changed := make([][]byte, 0)
+	var lastKey []byte
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		if isInternalKey(item.Key()) || item.Version() <= since {
+			continue
+		}
+		if lastKey != nil && string(lastKey) == string(item.Key()) {
+			continue
+		}
+		lastKey = item.KeyCopy(nil)
+		changed = append(changed, lastKey)
+	}
+	it.Close()
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	batch := db.internalIndex.NewBatch()
+	for _, key := range changed {
+		item, err := txn.Get(key)
+		if err == badger.ErrKeyNotFound {
+			batch.Delete(string(key))
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		if doc, err := db.decodeIndexValue(string(key), value); err == nil {
+			if err := batch.Index(string(key), doc); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := db.internalIndex.Batch(batch); err != nil {
+		return ErrIndexStoreTransactionFailed
+	}
+
+	return nil
+}
+
+// recoverIndexSwap moves a complete staging index in place of the current
+// index store and discards an incomplete one, every step can be repeated
+// so an interrupted swap is completed by calling it again
+func (db *Database) recoverIndexSwap() error {
+	stagingPath := filepath.Join(db.dbPath, reindexStagingDir)
+	oldPath := filepath.Join(db.dbPath, reindexOldDir)
+
+	if _, err := os.Stat(filepath.Join(stagingPath, reindexCompleteMarker)); err != nil {
+		if err := os.RemoveAll(stagingPath); err != nil {
+			return err
+		}
+		return os.RemoveAll(oldPath)
+	}
+
+	if err := os.MkdirAll(oldPath, os.FileMode(0700)); err != nil {
+		return err
+	}
+
+	for _, entry := range indexEntries {
+		newEntry := filepath.Join(stagingPath, entry)
+		if _, err := os.Stat(newEntry); err != nil {
+			// already moved
+			continue
+		}
+
+		currentEntry := filepath.Join(db.dbPath, entry)
+		if _, err := os.Stat(currentEntry); err == nil {
+			oldEntry := filepath.Join(oldPath, entry)
+			if err := os.RemoveAll(oldEntry); err != nil {
+				return err
+			}
+			if err := os.Rename(currentEntry, oldEntry); err != nil {
+				return err
+			}
+		}
+
+		if err := os.Rename(newEntry, currentEntry); err != nil {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(stagingPath); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(oldPath)
+}
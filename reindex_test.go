@@ -0,0 +1,234 @@
+package dodod
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestDatabase_Reindex(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.Reindex(nil); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := make([]interface{}, 0)
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		data = append(data, &MyTestDocument{Id: id, Name: "name " + id})
+	}
+	if err := db.Create(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// drift the index away from the database
+	if err := db.DeleteIndex([]interface{}{&MyTestDocument{Id: "1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.CreateIndex([]interface{}{&MyTestDocument{Id: "orphan"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calls := 0
+	progress, err := db.Reindex(&ReindexOptions{
+		BatchSize: 2,
+		Progress: func(progress *ReindexProgress) {
+			calls = calls + 1
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if progress.Indexed != 5 || progress.Skipped != 0 {
+		t.Fatalf("unexpected progress: %+v", progress)
+	}
+
+	if calls != 3 {
+		t.Fatalf("expected 3 progress calls, found: %d", calls)
+	}
+
+	if !db.IsIndexExists("1") || db.IsIndexExists("orphan") {
+		t.Fatalf("index should follow the database")
+	}
+
+	if count, err := db.GetInternalIndex().DocCount(); err != nil || count != 5 {
+		t.Fatalf("expected 5 indexed documents, found: %d, error: %v", count, err)
+	}
+
+	if _, err := os.Stat(dbPath + "/" + reindexStagingDir); !os.IsNotExist(err) {
+		t.Fatalf("staging index should be removed")
+	}
+
+	// the swapped index is used by writes
+	if err := db.Create([]interface{}{&MyTestDocument{Id: "6"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !db.IsIndexExists("6") {
+		t.Fatalf("document should be indexed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := db.ReindexContext(ctx, nil); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !db.IsIndexExists("6") {
+		t.Fatalf("cancelled reindex should keep the current index")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+
+	// an incomplete staging index is discarded by Open
+	if err := os.MkdirAll(dbPath+"/"+reindexStagingDir+"/store", os.FileMode(0700)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(dbPath + "/" + reindexStagingDir); !os.IsNotExist(err) {
+		t.Fatalf("staging index should be removed")
+	}
+
+	if !db.IsIndexExists("6") {
+		t.Fatalf("document should be indexed")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
+
+func TestDatabase_ReindexConcurrentWrites(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 50; i++ {
+			id := strconv.Itoa(i)
+			if err := db.Create([]interface{}{&MyTestDocument{Id: id, Name: "name " + id}}); err != nil {
+				done <- err
+				return
+			}
+			if _, err := db.Search(map[string]interface{}{}, "map"); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	if _, err := db.Reindex(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("write during reindex failed: %v", err)
+	}
+
+	if count, err := db.GetInternalIndex().DocCount(); err != nil || count != 50 {
+		t.Fatalf("expected 50 indexed documents, found: %d, error: %v", count, err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
+
+func TestDatabase_ReindexFailedSwap(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{&MyTestDocument{Id: "1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.DeleteIndex([]interface{}{&MyTestDocument{Id: "1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a file in place of the old index directory makes the swap fail
+	oldPath := filepath.Join(dbPath, reindexOldDir)
+	if err := ioutil.WriteFile(oldPath, []byte{}, os.FileMode(0600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.Reindex(nil); err == nil {
+		t.Fatalf("reindex should fail")
+	}
+
+	if db.IsDatabaseReady() {
+		t.Fatalf("database should not be ready")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+
+	// the complete staging index is swapped in by the next Open
+	if err := os.Remove(oldPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !db.IsIndexExists("1") {
+		t.Fatalf("document should be indexed")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
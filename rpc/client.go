@@ -0,0 +1,326 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/blevesearch/bleve"
+	"github.com/mkawserm/dodod"
+	"google.golang.org/grpc"
+)
+
+// Client implements dodod.Dodod using a remote Dodod service. The documents
+// are registered on the client as well to decode the documents read
+type Client struct {
+	client DododClient
+
+	typeNamespacedKeys bool
+
+	documentRegistryCache map[string]interface{}
+	fieldsRegistryCache   map[string]string
+}
+
+var _ dodod.Dodod = (*Client)(nil)
+
+// NewClient returns the client using the connection
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{
+		client:                NewDododClient(conn),
+		documentRegistryCache: make(map[string]interface{}),
+		fieldsRegistryCache:   make(map[string]string),
+	}
+}
+
+// SetTypeNamespacedKeys tells the client the server database uses
+// the type namespaced key layout, it must match the server
+func (c *Client) SetTypeNamespacedKeys(b bool) {
+	c.typeNamespacedKeys = b
+}
+
+// DocumentKey returns the key used by the server for the document type
+// and id, the key is accepted by Read
+func (c *Client) DocumentKey(docType string, id string) string {
+	if c.typeNamespacedKeys {
+		return dodod.TypeNamespacedKey(docType, id)
+	}
+	return id
+}
+
+// RegisterDocument registers the document to decode the documents of its type
+func (c *Client) RegisterDocument(d interface{}) error {
+	document, ok := d.(dodod.Document)
+	if !ok {
+		return dodod.ErrInvalidDocument
+	}
+
+	if _, exists := c.documentRegistryCache[document.Type()]; exists {
+		return dodod.ErrDocumentTypeAlreadyRegistered
+	}
+
+	fields := dodod.ExtractFields(document)
+	for k, v := range fields {
+		if f, exists := c.fieldsRegistryCache[k]; exists && f != v {
+			return dodod.ErrFieldTypeMismatch
+		}
+	}
+
+	for k, v := range fields {
+		c.fieldsRegistryCache[k] = v
+	}
+
+	c.documentRegistryCache[document.Type()] = document
+
+	return nil
+}
+
+func (c *Client) GetRegisteredFields() []string {
+	keys := make([]string, 0, len(c.fieldsRegistryCache))
+	for k := range c.fieldsRegistryCache {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (c *Client) GetRegisteredDocument() map[string]interface{} {
+	return c.documentRegistryCache
+}
+
+func (c *Client) Read(data []string) (uint64, []interface{}, error) {
+	return c.ReadContext(context.Background(), data)
+}
+
+// ReadContext is the context aware variant of Read, like the database
+// a document of a type which is not registered on the client is skipped
+// and not counted
+func (c *Client) ReadContext(ctx context.Context, data []string) (uint64, []interface{}, error) {
+	response, err := c.client.Read(ctx, &IdsRequest{Ids: data})
+	if err != nil {
+		return 0, nil, fromStatus(err)
+	}
+
+	output := make([]interface{}, 0, len(response.GetDocuments()))
+	for _, document := range response.GetDocuments() {
+		if doc, err := decodeDocument(c.documentRegistryCache, document); err == nil {
+			output = append(output, doc)
+		}
+	}
+
+	return uint64(len(output)), output, nil
+}
+
+func (c *Client) GetDocument(data []interface{}) (uint64, error) {
+	return c.GetDocumentContext(context.Background(), data)
+}
+
+// GetDocumentContext is the context aware variant of GetDocument
+func (c *Client) GetDocumentContext(ctx context.Context, data []interface{}) (uint64, error) {
+	// like the database the documents without id are skipped
+	targets := make([]interface{}, 0, len(data))
+	for _, d := range data {
+		if n, ok := d.(dodod.Document); ok && n.GetId() != "" {
+			targets = append(targets, d)
+		}
+	}
+
+	documents, err := encodeDocuments(targets)
+	if err != nil {
+		return 0, err
+	}
+
+	response, err := c.client.GetDocument(ctx, &DocumentsRequest{Documents: documents})
+	if err != nil {
+		return 0, fromStatus(err)
+	}
+
+	for i, document := range response.GetDocuments() {
+		if i < len(targets) {
+			if err := json.Unmarshal(document.GetData(), targets[i]); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return response.GetTotal(), nil
+}
+
+func (c *Client) GetDocumentWithError(data []string) (uint64, []interface{}, error) {
+	return c.GetDocumentWithErrorContext(context.Background(), data)
+}
+
+// GetDocumentWithErrorContext is the context aware variant of GetDocumentWithError
+func (c *Client) GetDocumentWithErrorContext(ctx context.Context, data []string) (uint64, []interface{}, error) {
+	response, err := c.client.GetDocumentWithError(ctx, &IdsRequest{Ids: data})
+	if err != nil {
+		return 0, nil, fromStatus(err)
+	}
+
+	output := make([]interface{}, len(data))
+	for i, result := range response.GetResults() {
+		if i >= len(output) {
+			break
+		}
+
+		switch {
+		case result.GetError() != "":
+			output[i] = restoreError(result.GetError())
+			if output[i] == nil {
+				output[i] = &remoteError{message: result.GetError()}
+			}
+		case result.GetDocument() != nil:
+			if doc, err := decodeDocument(c.documentRegistryCache, result.GetDocument()); err == nil {
+				output[i] = doc
+			} else {
+				output[i] = err
+			}
+		}
+	}
+
+	return response.GetTotal(), output, nil
+}
+
+// mutate sends the documents to the mutation of the server
+func (c *Client) mutate(ctx context.Context,
+	data []interface{},
+	mutation func(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error)) error {
+
+	documents, err := encodeDocuments(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = mutation(ctx, &DocumentsRequest{Documents: documents})
+	return fromStatus(err)
+}
+
+func (c *Client) Create(data []interface{}) error {
+	return c.CreateContext(context.Background(), data)
+}
+
+// CreateContext is the context aware variant of Create
+func (c *Client) CreateContext(ctx context.Context, data []interface{}) error {
+	return c.mutate(ctx, data, c.client.Create)
+}
+
+func (c *Client) Update(data []interface{}) error {
+	return c.UpdateContext(context.Background(), data)
+}
+
+// UpdateContext is the context aware variant of Update
+func (c *Client) UpdateContext(ctx context.Context, data []interface{}) error {
+	return c.mutate(ctx, data, c.client.Update)
+}
+
+func (c *Client) Delete(data []interface{}) error {
+	return c.DeleteContext(context.Background(), data)
+}
+
+// DeleteContext is the context aware variant of Delete
+func (c *Client) DeleteContext(ctx context.Context, data []interface{}) error {
+	return c.mutate(ctx, data, c.client.Delete)
+}
+
+func (c *Client) CreateIndex(data []interface{}) error {
+	return c.CreateIndexContext(context.Background(), data)
+}
+
+// CreateIndexContext is the context aware variant of CreateIndex
+func (c *Client) CreateIndexContext(ctx context.Context, data []interface{}) error {
+	return c.mutate(ctx, data, c.client.CreateIndex)
+}
+
+func (c *Client) UpdateIndex(data []interface{}) error {
+	return c.UpdateIndexContext(context.Background(), data)
+}
+
+// UpdateIndexContext is the context aware variant of UpdateIndex
+func (c *Client) UpdateIndexContext(ctx context.Context, data []interface{}) error {
+	return c.mutate(ctx, data, c.client.UpdateIndex)
+}
+
+func (c *Client) DeleteIndex(data []interface{}) error {
+	return c.DeleteIndexContext(context.Background(), data)
+}
+
+// DeleteIndexContext is the context aware variant of DeleteIndex
+func (c *Client) DeleteIndexContext(ctx context.Context, data []interface{}) error {
+	return c.mutate(ctx, data, c.client.DeleteIndex)
+}
+
+func (c *Client) CreateDocument(data []interface{}) error {
+	return c.CreateDocumentContext(context.Background(), data)
+}
+
+// CreateDocumentContext is the context aware variant of CreateDocument
+func (c *Client) CreateDocumentContext(ctx context.Context, data []interface{}) error {
+	return c.mutate(ctx, data, c.client.CreateDocument)
+}
+
+func (c *Client) UpdateDocument(data []interface{}) error {
+	return c.UpdateDocumentContext(context.Background(), data)
+}
+
+// UpdateDocumentContext is the context aware variant of UpdateDocument
+func (c *Client) UpdateDocumentContext(ctx context.Context, data []interface{}) error {
+	return c.mutate(ctx, data, c.client.UpdateDocument)
+}
+
+func (c *Client) DeleteDocument(data []interface{}) error {
+	return c.DeleteDocumentContext(context.Background(), data)
+}
+
+// DeleteDocumentContext is the context aware variant of DeleteDocument
+func (c *Client) DeleteDocumentContext(ctx context.Context, data []interface{}) error {
+	return c.mutate(ctx, data, c.client.DeleteDocument)
+}
+
+func (c *Client) Search(input map[string]interface{}, outputType string) (interface{}, error) {
+	return c.SearchContext(context.Background(), input, outputType)
+}
+
+// SearchContext is the context aware variant of Search, the output types
+// match the database and the documents of the hits are decoded using the
+// documents registered on the client
+func (c *Client) SearchContext(ctx context.Context, input map[string]interface{}, outputType string) (interface{}, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, dodod.ErrJSONParseFailed
+	}
+
+	response, err := c.client.Search(ctx, &SearchRequest{Input: data, OutputType: outputType})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	switch outputType {
+	case "bytes":
+		return response.GetOutput(), nil
+
+	case "map", "mapIncludeData":
+		output := map[string]interface{}{}
+		if err := json.Unmarshal(response.GetOutput(), &output); err != nil {
+			return nil, err
+		}
+
+		hits, _ := output["hits"].([]interface{})
+		for i, document := range response.GetHitDocuments() {
+			if i >= len(hits) || document.GetType() == "" {
+				continue
+			}
+
+			if hit, ok := hits[i].(map[string]interface{}); ok {
+				if doc, err := decodeDocument(c.documentRegistryCache, document); err == nil {
+					hit["data"] = doc
+				}
+			}
+		}
+
+		return output, nil
+
+	default:
+		searchResult := &bleve.SearchResult{}
+		if err := json.Unmarshal(response.GetOutput(), searchResult); err != nil {
+			return nil, err
+		}
+		return searchResult, nil
+	}
+}
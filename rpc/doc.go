@@ -0,0 +1,10 @@
+// Package rpc serves a dodod.Dodod implementation over gRPC.
+//
+// The Dodod service of dodod.proto mirrors the Query, Mutation and Search
+// interfaces. Documents travel as their document type, id and json data,
+// both sides decode them using their registered documents. NewServer wraps
+// any dodod.Dodod implementation and NewClient returns a dodod.Dodod backed
+// by a remote server, so a Database and a Client are interchangeable.
+package rpc
+
+//go:generate protoc -I .. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative ../rpc/dodod.proto
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/mkawserm/dodod"
+)
+
+// encodeDocument encodes the document with its type and id
+func encodeDocument(d interface{}) (*Document, error) {
+	document, ok := d.(dodod.Document)
+	if !ok {
+		return nil, dodod.ErrInvalidDocument
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{Type: document.Type(), Id: document.GetId(), Data: data}, nil
+}
+
+// encodeDocuments encodes every document
+func encodeDocuments(data []interface{}) ([]*Document, error) {
+	documents := make([]*Document, 0, len(data))
+	for _, d := range data {
+		document, err := encodeDocument(d)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	return documents, nil
+}
+
+// decodeDocument decodes the document into a new document of its registered type
+func decodeDocument(registry map[string]interface{}, document *Document) (interface{}, error) {
+	registered, exists := registry[document.GetType()]
+	if !exists {
+		return nil, dodod.ErrDocumentTypeIsNotRegistered
+	}
+
+	doc := reflect.New(reflect.Indirect(reflect.ValueOf(registered)).Type()).Interface()
+	if err := json.Unmarshal(document.GetData(), doc); err != nil {
+		return nil, dodod.ErrInvalidDocument
+	}
+
+	if n, ok := doc.(dodod.Document); !ok || n.GetId() != document.GetId() {
+		return nil, dodod.ErrInvalidDocument
+	}
+
+	return doc, nil
+}
+
+// decodeDocuments decodes every document
+func decodeDocuments(registry map[string]interface{}, documents []*Document) ([]interface{}, error) {
+	data := make([]interface{}, 0, len(documents))
+	for _, document := range documents {
+		doc, err := decodeDocument(registry, document)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, doc)
+	}
+	return data, nil
+}
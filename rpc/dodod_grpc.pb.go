@@ -0,0 +1,533 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// DododClient is the client API for Dodod service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DododClient interface {
+	Read(ctx context.Context, in *IdsRequest, opts ...grpc.CallOption) (*DocumentsResponse, error)
+	GetDocument(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*DocumentsResponse, error)
+	GetDocumentWithError(ctx context.Context, in *IdsRequest, opts ...grpc.CallOption) (*DocumentResultsResponse, error)
+	Create(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error)
+	Update(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error)
+	Delete(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error)
+	CreateIndex(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error)
+	UpdateIndex(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error)
+	DeleteIndex(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error)
+	CreateDocument(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error)
+	UpdateDocument(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error)
+	DeleteDocument(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error)
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+}
+
+type dododClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDododClient(cc grpc.ClientConnInterface) DododClient {
+	return &dododClient{cc}
+}
+
+func (c *dododClient) Read(ctx context.Context, in *IdsRequest, opts ...grpc.CallOption) (*DocumentsResponse, error) {
+	out := new(DocumentsResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/Read", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) GetDocument(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*DocumentsResponse, error) {
+	out := new(DocumentsResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/GetDocument", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) GetDocumentWithError(ctx context.Context, in *IdsRequest, opts ...grpc.CallOption) (*DocumentResultsResponse, error) {
+	out := new(DocumentResultsResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/GetDocumentWithError", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) Create(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error) {
+	out := new(MutationResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/Create", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) Update(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error) {
+	out := new(MutationResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/Update", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) Delete(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error) {
+	out := new(MutationResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) CreateIndex(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error) {
+	out := new(MutationResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/CreateIndex", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) UpdateIndex(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error) {
+	out := new(MutationResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/UpdateIndex", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) DeleteIndex(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error) {
+	out := new(MutationResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/DeleteIndex", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) CreateDocument(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error) {
+	out := new(MutationResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/CreateDocument", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) UpdateDocument(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error) {
+	out := new(MutationResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/UpdateDocument", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) DeleteDocument(ctx context.Context, in *DocumentsRequest, opts ...grpc.CallOption) (*MutationResponse, error) {
+	out := new(MutationResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/DeleteDocument", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dododClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, "/dodod.Dodod/Search", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DododServer is the server API for Dodod service.
+// All implementations must embed UnimplementedDododServer
+// for forward compatibility
+type DododServer interface {
+	Read(context.Context, *IdsRequest) (*DocumentsResponse, error)
+	GetDocument(context.Context, *DocumentsRequest) (*DocumentsResponse, error)
+	GetDocumentWithError(context.Context, *IdsRequest) (*DocumentResultsResponse, error)
+	Create(context.Context, *DocumentsRequest) (*MutationResponse, error)
+	Update(context.Context, *DocumentsRequest) (*MutationResponse, error)
+	Delete(context.Context, *DocumentsRequest) (*MutationResponse, error)
+	CreateIndex(context.Context, *DocumentsRequest) (*MutationResponse, error)
+	UpdateIndex(context.Context, *DocumentsRequest) (*MutationResponse, error)
+	DeleteIndex(context.Context, *DocumentsRequest) (*MutationResponse, error)
+	CreateDocument(context.Context, *DocumentsRequest) (*MutationResponse, error)
+	UpdateDocument(context.Context, *DocumentsRequest) (*MutationResponse, error)
+	DeleteDocument(context.Context, *DocumentsRequest) (*MutationResponse, error)
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	mustEmbedUnimplementedDododServer()
+}
+
+// UnimplementedDododServer must be embedded to have forward compatible implementations.
+type UnimplementedDododServer struct {
+}
+
+func (UnimplementedDododServer) Read(context.Context, *IdsRequest) (*DocumentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Read not implemented")
+}
+func (UnimplementedDododServer) GetDocument(context.Context, *DocumentsRequest) (*DocumentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDocument not implemented")
+}
+func (UnimplementedDododServer) GetDocumentWithError(context.Context, *IdsRequest) (*DocumentResultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDocumentWithError not implemented")
+}
+func (UnimplementedDododServer) Create(context.Context, *DocumentsRequest) (*MutationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedDododServer) Update(context.Context, *DocumentsRequest) (*MutationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedDododServer) Delete(context.Context, *DocumentsRequest) (*MutationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedDododServer) CreateIndex(context.Context, *DocumentsRequest) (*MutationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateIndex not implemented")
+}
+func (UnimplementedDododServer) UpdateIndex(context.Context, *DocumentsRequest) (*MutationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateIndex not implemented")
+}
+func (UnimplementedDododServer) DeleteIndex(context.Context, *DocumentsRequest) (*MutationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteIndex not implemented")
+}
+func (UnimplementedDododServer) CreateDocument(context.Context, *DocumentsRequest) (*MutationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDocument not implemented")
+}
+func (UnimplementedDododServer) UpdateDocument(context.Context, *DocumentsRequest) (*MutationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateDocument not implemented")
+}
+func (UnimplementedDododServer) DeleteDocument(context.Context, *DocumentsRequest) (*MutationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDocument not implemented")
+}
+func (UnimplementedDododServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedDododServer) mustEmbedUnimplementedDododServer() {}
+
+// UnsafeDododServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DododServer will
+// result in compilation errors.
+type UnsafeDododServer interface {
+	mustEmbedUnimplementedDododServer()
+}
+
+func RegisterDododServer(s grpc.ServiceRegistrar, srv DododServer) {
+	s.RegisterService(&Dodod_ServiceDesc, srv)
+}
+
+func _Dodod_Read_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).Read(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/Read",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).Read(ctx, req.(*IdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_GetDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).GetDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/GetDocument",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).GetDocument(ctx, req.(*DocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_GetDocumentWithError_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).GetDocumentWithError(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/GetDocumentWithError",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).GetDocumentWithError(ctx, req.(*IdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/Create",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).Create(ctx, req.(*DocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/Update",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).Update(ctx, req.(*DocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).Delete(ctx, req.(*DocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_CreateIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).CreateIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/CreateIndex",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).CreateIndex(ctx, req.(*DocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_UpdateIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).UpdateIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/UpdateIndex",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).UpdateIndex(ctx, req.(*DocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_DeleteIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).DeleteIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/DeleteIndex",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).DeleteIndex(ctx, req.(*DocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_CreateDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).CreateDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/CreateDocument",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).CreateDocument(ctx, req.(*DocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_UpdateDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).UpdateDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/UpdateDocument",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).UpdateDocument(ctx, req.(*DocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_DeleteDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).DeleteDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/DeleteDocument",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).DeleteDocument(ctx, req.(*DocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dodod_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DododServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dodod.Dodod/Search",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DododServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Dodod_ServiceDesc is the grpc.ServiceDesc for Dodod service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Dodod_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dodod.Dodod",
+	HandlerType: (*DododServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Read",
+			Handler:    _Dodod_Read_Handler,
+		},
+		{
+			MethodName: "GetDocument",
+			Handler:    _Dodod_GetDocument_Handler,
+		},
+		{
+			MethodName: "GetDocumentWithError",
+			Handler:    _Dodod_GetDocumentWithError_Handler,
+		},
+		{
+			MethodName: "Create",
+			Handler:    _Dodod_Create_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _Dodod_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Dodod_Delete_Handler,
+		},
+		{
+			MethodName: "CreateIndex",
+			Handler:    _Dodod_CreateIndex_Handler,
+		},
+		{
+			MethodName: "UpdateIndex",
+			Handler:    _Dodod_UpdateIndex_Handler,
+		},
+		{
+			MethodName: "DeleteIndex",
+			Handler:    _Dodod_DeleteIndex_Handler,
+		},
+		{
+			MethodName: "CreateDocument",
+			Handler:    _Dodod_CreateDocument_Handler,
+		},
+		{
+			MethodName: "UpdateDocument",
+			Handler:    _Dodod_UpdateDocument_Handler,
+		},
+		{
+			MethodName: "DeleteDocument",
+			Handler:    _Dodod_DeleteDocument_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _Dodod_Search_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc/dodod.proto",
+}
@@ -0,0 +1,126 @@
+package rpc
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/mkawserm/dodod"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// knownErrors are the errors restored by the client from the error message
+var knownErrors = []error{
+	dodod.ErrInvalidData,
+	dodod.ErrInvalidDocument,
+	dodod.ErrDatabaseTransactionFailed,
+	dodod.ErrIndexStoreTransactionFailed,
+	dodod.ErrIdCanNotBeEmpty,
+	dodod.ErrIdIsReserved,
+	dodod.ErrUniqueConstraintViolation,
+	dodod.ErrVersionConflict,
+	dodod.ErrDocumentNotFound,
+	dodod.ErrDocumentAlreadyExists,
+	dodod.ErrDocumentIsExpired,
+	dodod.ErrDatabaseIsNotOpen,
+	dodod.ErrDatabaseIsReadOnly,
+	dodod.ErrFieldTypeMismatch,
+	dodod.ErrDocumentTypeIsNotRegistered,
+	dodod.ErrInvalidSearchRequest,
+	dodod.ErrJSONParseFailed,
+	badger.ErrKeyNotFound,
+}
+
+// remoteError is an error reported by the server which
+// wraps a known error with more details
+type remoteError struct {
+	message string
+	err     error
+}
+
+func (e *remoteError) Error() string {
+	return e.message
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.err
+}
+
+// codeOf maps an error to the status code of the response
+func codeOf(err error) codes.Code {
+	switch {
+	case errors.Is(err, dodod.ErrDocumentNotFound),
+		errors.Is(err, badger.ErrKeyNotFound):
+		return codes.NotFound
+	case errors.Is(err, dodod.ErrDocumentAlreadyExists):
+		return codes.AlreadyExists
+	case errors.Is(err, dodod.ErrUniqueConstraintViolation),
+		errors.Is(err, dodod.ErrVersionConflict):
+		return codes.Aborted
+	case errors.Is(err, dodod.ErrDatabaseIsNotOpen),
+		errors.Is(err, dodod.ErrDatabaseIsReadOnly):
+		return codes.FailedPrecondition
+	case errors.Is(err, dodod.ErrInvalidData),
+		errors.Is(err, dodod.ErrInvalidDocument),
+		errors.Is(err, dodod.ErrIdCanNotBeEmpty),
+		errors.Is(err, dodod.ErrIdIsReserved),
+		errors.Is(err, dodod.ErrDocumentIsExpired),
+		errors.Is(err, dodod.ErrDocumentTypeIsNotRegistered),
+		errors.Is(err, dodod.ErrInvalidSearchRequest),
+		errors.Is(err, dodod.ErrJSONParseFailed):
+		return codes.InvalidArgument
+	default:
+		return codes.Internal
+	}
+}
+
+// toStatus converts an error of the store into a status error
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	return status.Error(codeOf(err), err.Error())
+}
+
+// fromStatus converts a status error returned by the server back into
+// the known error it was created from
+func fromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.Unknown || st.Code() == codes.Unavailable ||
+		st.Code() == codes.Canceled || st.Code() == codes.DeadlineExceeded {
+		return err
+	}
+
+	if known := restoreError(st.Message()); known != nil {
+		return known
+	}
+
+	return err
+}
+
+// restoreError returns the known error of the message, a message starting
+// with the message of a known error is wrapped around it
+func restoreError(message string) error {
+	var match error
+	for _, known := range knownErrors {
+		if message == known.Error() {
+			return known
+		}
+
+		if strings.HasPrefix(message, known.Error()) &&
+			(match == nil || len(known.Error()) > len(match.Error())) {
+			match = known
+		}
+	}
+
+	if match != nil {
+		return &remoteError{message: message, err: match}
+	}
+
+	return nil
+}
@@ -0,0 +1,254 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"runtime/debug"
+	"testing"
+
+	"github.com/blevesearch/bleve"
+	"github.com/dgraph-io/badger/v2"
+	"github.com/mkawserm/dodod"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type Book struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
+
+func (b *Book) Type() string {
+	return "Book"
+}
+
+func (b *Book) GetId() string {
+	return b.Id
+}
+
+type Author struct {
+	Id string `json:"id"`
+}
+
+func (a *Author) Type() string {
+	return "Author"
+}
+
+func (a *Author) GetId() string {
+	return a.Id
+}
+
+// newTestClient serves a database over an in memory connection
+func newTestClient(t *testing.T, path string, typeNamespacedKeys bool) (*Client, func()) {
+	t.Helper()
+
+	db := &dodod.Database{}
+	db.SetupDefaults()
+	db.SetDbPath(path)
+	db.SetTypeNamespacedKeys(typeNamespacedKeys)
+
+	if err := db.RegisterDocument(&Book{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterDododServer(server, NewServer(db))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return NewClient(conn), func() {
+		_ = conn.Close()
+		server.Stop()
+		_ = db.Close()
+		_ = os.RemoveAll(path)
+		debug.FreeOSMemory()
+	}
+}
+
+func matchQuery(term string) map[string]interface{} {
+	return map[string]interface{}{
+		"query": map[string]interface{}{"name": "Match", "p": map[string]interface{}{"match": term}},
+	}
+}
+
+func TestClient_Dodod(t *testing.T) {
+	client, cleanup := newTestClient(t, "/tmp/dodod-rpc", false)
+	defer cleanup()
+
+	var store dodod.Dodod = client
+
+	if err := store.RegisterDocument(&Book{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.RegisterDocument(&Book{}); err != dodod.ErrDocumentTypeAlreadyRegistered {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.RegisterDocument(&Author{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := store.Create([]interface{}{
+		&Book{Id: "1", Title: "first book"},
+		&Book{Id: "2", Title: "second book"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	total, docs, err := store.Read([]string{"1", "2", "3"})
+	if err != nil || total != 2 || docs[1].(*Book).Title != "second book" {
+		t.Fatalf("unexpected read %d %v: %v", total, docs, err)
+	}
+
+	book := &Book{Id: "1"}
+	missing := &Book{Id: "3"}
+	if total, err := store.GetDocument([]interface{}{book, missing}); err != nil || total != 1 {
+		t.Fatalf("unexpected get document %d: %v", total, err)
+	}
+	if book.Title != "first book" || missing.Title != "" {
+		t.Fatalf("document should be filled: %+v %+v", book, missing)
+	}
+
+	total, docs, err = store.GetDocumentWithError([]string{"2", "3"})
+	if err != nil || total != 1 || docs[0].(*Book).Id != "2" || docs[1] != badger.ErrKeyNotFound {
+		t.Fatalf("unexpected get document with error %d %v: %v", total, docs, err)
+	}
+
+	if err := store.Update([]interface{}{&Book{Id: "2", Title: "changed"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output, err := store.Search(matchQuery("book"), "map")
+	if err != nil || output.(map[string]interface{})["total_hits"] != float64(1) {
+		t.Fatalf("unexpected search %v: %v", output, err)
+	}
+
+	output, err = store.Search(matchQuery("changed"), "mapIncludeData")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hit := output.(map[string]interface{})["hits"].([]interface{})[0].(map[string]interface{})
+	if hit["data"].(*Book).Title != "changed" {
+		t.Fatalf("hit should include the document: %v", hit)
+	}
+
+	if output, err := store.Search(matchQuery("changed"), "bytes"); err != nil || len(output.([]byte)) == 0 {
+		t.Fatalf("unexpected search %v: %v", output, err)
+	}
+
+	output, err = store.Search(matchQuery("changed"), "bleveSearchResult")
+	if err != nil || output.(*bleve.SearchResult).Total != 1 {
+		t.Fatalf("unexpected search %v: %v", output, err)
+	}
+
+	if err := store.Delete([]interface{}{&Book{Id: "1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total, _, _ := store.Read([]string{"1"}); total != 0 {
+		t.Fatalf("document should be deleted")
+	}
+
+	if err := store.DeleteIndex([]interface{}{&Book{Id: "2"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output, _ := store.Search(matchQuery("changed"), "map"); output.(map[string]interface{})["total_hits"] != float64(0) {
+		t.Fatalf("index should be deleted")
+	}
+	if total, _, _ := store.Read([]string{"2"}); total != 1 {
+		t.Fatalf("document should be kept")
+	}
+}
+
+func TestClient_TypeNamespacedKeys(t *testing.T) {
+	client, cleanup := newTestClient(t, "/tmp/dodod-rpc", true)
+	defer cleanup()
+
+	client.SetTypeNamespacedKeys(true)
+	if err := client.RegisterDocument(&Book{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.Create([]interface{}{&Book{Id: "1", Title: "first book"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	key := client.DocumentKey("Book", "1")
+	if key != "Book:1" {
+		t.Fatalf("unexpected key %q", key)
+	}
+
+	if total, _, _ := client.Read([]string{"1"}); total != 0 {
+		t.Fatalf("the id should not be the key of a namespaced server")
+	}
+	total, docs, err := client.Read([]string{key})
+	if err != nil || total != 1 || docs[0].(*Book).Title != "first book" {
+		t.Fatalf("unexpected read %d %v: %v", total, docs, err)
+	}
+
+	output, err := client.Search(matchQuery("first"), "map")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hit := output.(map[string]interface{})["hits"].([]interface{})[0].(map[string]interface{})
+	if hit["id"] != key {
+		t.Fatalf("hit id should be the document key: %v", hit)
+	}
+
+	if err := client.Delete([]interface{}{&Book{Id: "1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total, _, _ := client.Read([]string{key}); total != 0 {
+		t.Fatalf("document should be deleted")
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	client, cleanup := newTestClient(t, "/tmp/dodod-rpc", false)
+	defer cleanup()
+
+	if err := client.RegisterDocument(&Author{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.Create([]interface{}{&Author{Id: "1"}}); err != dodod.ErrDocumentTypeIsNotRegistered {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Book is registered on the server only
+	if err := client.Create([]interface{}{&Book{Id: "1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// like the database a document which can not be decoded is skipped
+	if total, docs, err := client.Read([]string{"1"}); err != nil || total != 0 || len(docs) != 0 {
+		t.Fatalf("unexpected read %d %v: %v", total, docs, err)
+	}
+
+	if err := client.Create([]interface{}{"not a document"}); err != dodod.ErrInvalidDocument {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.Create([]interface{}{&Book{Title: "no id"}}); err != dodod.ErrIdCanNotBeEmpty {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := client.Search(map[string]interface{}{"query": map[string]interface{}{"name": "Unknown"}}, "map")
+	if !errors.Is(err, dodod.ErrInvalidSearchRequest) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mkawserm/dodod"
+)
+
+// Server implements the Dodod service on top of a dodod.Dodod implementation
+type Server struct {
+	UnimplementedDododServer
+
+	store dodod.Dodod
+}
+
+// NewServer returns the server of the store, register it on a grpc.Server
+// using RegisterDododServer. The store must be open
+func NewServer(store dodod.Dodod) *Server {
+	return &Server{store: store}
+}
+
+// documentsResponse encodes the documents read by the store
+func documentsResponse(total uint64, data []interface{}) (*DocumentsResponse, error) {
+	documents, err := encodeDocuments(data)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &DocumentsResponse{Total: total, Documents: documents}, nil
+}
+
+func (s *Server) Read(_ context.Context, request *IdsRequest) (*DocumentsResponse, error) {
+	total, data, err := s.store.Read(request.GetIds())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return documentsResponse(total, data)
+}
+
+func (s *Server) GetDocument(_ context.Context, request *DocumentsRequest) (*DocumentsResponse, error) {
+	data, err := decodeDocuments(s.store.GetRegisteredDocument(), request.GetDocuments())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	total, err := s.store.GetDocument(data)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return documentsResponse(total, data)
+}
+
+func (s *Server) GetDocumentWithError(_ context.Context, request *IdsRequest) (*DocumentResultsResponse, error) {
+	total, data, err := s.store.GetDocumentWithError(request.GetIds())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	results := make([]*DocumentResult, 0, len(data))
+	for _, d := range data {
+		result := &DocumentResult{}
+		if readError, ok := d.(error); ok {
+			result.Error = readError.Error()
+		} else if d != nil {
+			document, err := encodeDocument(d)
+			if err != nil {
+				return nil, toStatus(err)
+			}
+			result.Document = document
+		}
+		results = append(results, result)
+	}
+
+	return &DocumentResultsResponse{Total: total, Results: results}, nil
+}
+
+// mutate decodes the documents of the request and passes them to the mutation
+func (s *Server) mutate(request *DocumentsRequest, mutation func(data []interface{}) error) (*MutationResponse, error) {
+	data, err := decodeDocuments(s.store.GetRegisteredDocument(), request.GetDocuments())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	if err := mutation(data); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &MutationResponse{}, nil
+}
+
+func (s *Server) Create(_ context.Context, request *DocumentsRequest) (*MutationResponse, error) {
+	return s.mutate(request, s.store.Create)
+}
+
+func (s *Server) Update(_ context.Context, request *DocumentsRequest) (*MutationResponse, error) {
+	return s.mutate(request, s.store.Update)
+}
+
+func (s *Server) Delete(_ context.Context, request *DocumentsRequest) (*MutationResponse, error) {
+	return s.mutate(request, s.store.Delete)
+}
+
+func (s *Server) CreateIndex(_ context.Context, request *DocumentsRequest) (*MutationResponse, error) {
+	return s.mutate(request, s.store.CreateIndex)
+}
+
+func (s *Server) UpdateIndex(_ context.Context, request *DocumentsRequest) (*MutationResponse, error) {
+	return s.mutate(request, s.store.UpdateIndex)
+}
+
+func (s *Server) DeleteIndex(_ context.Context, request *DocumentsRequest) (*MutationResponse, error) {
+	return s.mutate(request, s.store.DeleteIndex)
+}
+
+func (s *Server) CreateDocument(_ context.Context, request *DocumentsRequest) (*MutationResponse, error) {
+	return s.mutate(request, s.store.CreateDocument)
+}
+
+func (s *Server) UpdateDocument(_ context.Context, request *DocumentsRequest) (*MutationResponse, error) {
+	return s.mutate(request, s.store.UpdateDocument)
+}
+
+func (s *Server) DeleteDocument(_ context.Context, request *DocumentsRequest) (*MutationResponse, error) {
+	return s.mutate(request, s.store.DeleteDocument)
+}
+
+func (s *Server) Search(_ context.Context, request *SearchRequest) (*SearchResponse, error) {
+	input := make(map[string]interface{})
+	if err := json.Unmarshal(request.GetInput(), &input); err != nil {
+		return nil, toStatus(dodod.ErrJSONParseFailed)
+	}
+
+	output, err := s.store.Search(input, request.GetOutputType())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	if data, ok := output.([]byte); ok {
+		return &SearchResponse{Output: data}, nil
+	}
+
+	response := &SearchResponse{}
+	if m, ok := output.(map[string]interface{}); ok && request.GetOutputType() == "mapIncludeData" {
+		hits, _ := m["hits"].([]interface{})
+		for _, h := range hits {
+			document := &Document{}
+			if hit, ok := h.(map[string]interface{}); ok && hit["data"] != nil {
+				document, err = encodeDocument(hit["data"])
+				if err != nil {
+					return nil, toStatus(err)
+				}
+				delete(hit, "data")
+			}
+			response.HitDocuments = append(response.HitDocuments, document)
+		}
+	}
+
+	response.Output, err = json.Marshal(output)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,431 @@
+package dodod
+
+import (
+	"fmt"
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search/query"
+)
+
+// ParseQuery builds a bleve query from the query DSL used by Search,
+// the DSL is a map of the query name and its parameters
+//
+//	{"name": "Match", "p": {"match": "text", "field": "name"}}
+//
+// Range queries (NumericRange, DateRange and TermRange) take min/max
+// or start/end bounds with optional inclusive_* flags
+//
+//	{"name": "NumericRange", "p": {"min": 10, "max": 20, "field": "price"}}
+//
+// Compound queries (Boolean, Conjunction, Disjunction and Boost) take
+// child queries of the same shape
+//
+//	{"name": "Boolean", "p": {
+//		"must": [{"name": "Term", "p": {"term": "1", "field": "id"}}],
+//		"must_not": [{"name": "Match", "p": {"match": "test"}}]}}
+//
+// Numbers may be provided as float64 and lists as []interface{}, so the
+// query can come straight from json.Unmarshal. An unknown query name,
+// a missing required parameter or a parameter of the wrong type results
+// in a SearchRequestError naming the offending key
+func ParseQuery(val map[string]interface{}) (query.Query, error) {
+	return parseQuery(val, "")
+}
+
+func parseQuery(val map[string]interface{}, prefix string) (query.Query, error) {
+	node := newSearchParams(val, prefix)
+
+	name, nameFound, err := node.String("name")
+	if err != nil {
+		return nil, err
+	}
+	if !nameFound {
+		return nil, node.fail("name", "is required")
+	}
+
+	pMap, _, err := node.Map("p")
+	if err != nil {
+		return nil, err
+	}
+	p := newSearchParams(pMap, prefix+"p.")
+
+	// required returns the string parameter or an error if it is missing
+	required := func(k string) (string, error) {
+		v, found, err := p.String(k)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			return "", p.fail(k, "is required")
+		}
+		return v, nil
+	}
+
+	field, _, err := p.String("field")
+	if err != nil {
+		return nil, err
+	}
+
+	// Switch to bleveQuery type
+	switch name {
+
+	case "QueryString":
+		q, err := required("q")
+		if err != nil {
+			return nil, err
+		}
+
+		return bleve.NewQueryStringQuery(q), nil
+
+	case "Fuzzy":
+		term, err := required("term")
+		if err != nil {
+			return nil, err
+		}
+
+		fuzzyQuery := bleve.NewFuzzyQuery(term)
+
+		if fuzziness, found, err := p.Int("fuzziness"); err != nil {
+			return nil, err
+		} else if found {
+			fuzzyQuery.Fuzziness = fuzziness
+		}
+
+		if field != "" {
+			fuzzyQuery.SetField(field)
+		}
+
+		if prefixLength, found, err := p.Int("prefix_length"); err != nil {
+			return nil, err
+		} else if found {
+			fuzzyQuery.SetPrefix(prefixLength)
+		}
+
+		return fuzzyQuery, nil
+
+	case "Regexp":
+		regexp, err := required("regexp")
+		if err != nil {
+			return nil, err
+		}
+
+		regexpQuery := bleve.NewRegexpQuery(regexp)
+		if field != "" {
+			regexpQuery.SetField(field)
+		}
+
+		return regexpQuery, nil
+
+	case "Term":
+		term, err := required("term")
+		if err != nil {
+			return nil, err
+		}
+
+		termQuery := bleve.NewTermQuery(term)
+		if field != "" {
+			termQuery.SetField(field)
+		}
+
+		return termQuery, nil
+
+	case "MatchPhrase":
+		matchPhrase, err := required("match_phrase")
+		if err != nil {
+			return nil, err
+		}
+
+		matchPhraseQuery := bleve.NewMatchPhraseQuery(matchPhrase)
+		if field != "" {
+			matchPhraseQuery.SetField(field)
+		}
+
+		return matchPhraseQuery, nil
+
+	case "Match":
+		match, err := required("match")
+		if err != nil {
+			return nil, err
+		}
+
+		matchQuery := bleve.NewMatchQuery(match)
+		if field != "" {
+			matchQuery.SetField(field)
+		}
+
+		return matchQuery, nil
+
+	case "Prefix":
+		prefixValue, err := required("prefix")
+		if err != nil {
+			return nil, err
+		}
+
+		prefixQuery := bleve.NewPrefixQuery(prefixValue)
+		if field != "" {
+			prefixQuery.SetField(field)
+		}
+
+		return prefixQuery, nil
+
+	case "Wildcard":
+		wildcard, err := required("wildcard")
+		if err != nil {
+			return nil, err
+		}
+
+		wildcardQuery := bleve.NewWildcardQuery(wildcard)
+		if field != "" {
+			wildcardQuery.SetField(field)
+		}
+
+		return wildcardQuery, nil
+
+	case "GeoDistance":
+		lon, lonFound, err := p.Float64("lon")
+		if err != nil {
+			return nil, err
+		}
+		if !lonFound {
+			return nil, p.fail("lon", "is required")
+		}
+
+		lat, latFound, err := p.Float64("lat")
+		if err != nil {
+			return nil, err
+		}
+		if !latFound {
+			return nil, p.fail("lat", "is required")
+		}
+
+		distance, err := required("distance")
+		if err != nil {
+			return nil, err
+		}
+
+		geoDistanceQuery := bleve.NewGeoDistanceQuery(lon, lat, distance)
+		if field != "" {
+			geoDistanceQuery.SetField(field)
+		}
+
+		return geoDistanceQuery, nil
+
+	case "NumericRange":
+		min, minFound, err := p.Float64("min")
+		if err != nil {
+			return nil, err
+		}
+		max, maxFound, err := p.Float64("max")
+		if err != nil {
+			return nil, err
+		}
+		if !minFound && !maxFound {
+			return nil, p.fail("min", "numeric range query requires min or max")
+		}
+
+		var minPtr *float64
+		var maxPtr *float64
+		if minFound {
+			minPtr = &min
+		}
+		if maxFound {
+			maxPtr = &max
+		}
+
+		inclusiveMin, err := boolParam(p, "inclusive_min")
+		if err != nil {
+			return nil, err
+		}
+		inclusiveMax, err := boolParam(p, "inclusive_max")
+		if err != nil {
+			return nil, err
+		}
+
+		numericRangeQuery := bleve.NewNumericRangeInclusiveQuery(minPtr, maxPtr, inclusiveMin, inclusiveMax)
+		if field != "" {
+			numericRangeQuery.SetField(field)
+		}
+
+		return numericRangeQuery, nil
+
+	case "DateRange":
+		start, startFound, err := p.Time("start")
+		if err != nil {
+			return nil, err
+		}
+		end, endFound, err := p.Time("end")
+		if err != nil {
+			return nil, err
+		}
+		if !startFound && !endFound {
+			return nil, p.fail("start", "date range query requires start or end")
+		}
+
+		inclusiveStart, err := boolParam(p, "inclusive_start")
+		if err != nil {
+			return nil, err
+		}
+		inclusiveEnd, err := boolParam(p, "inclusive_end")
+		if err != nil {
+			return nil, err
+		}
+
+		dateRangeQuery := bleve.NewDateRangeInclusiveQuery(start, end, inclusiveStart, inclusiveEnd)
+		if field != "" {
+			dateRangeQuery.SetField(field)
+		}
+
+		return dateRangeQuery, nil
+
+	case "TermRange":
+		min, minFound, err := p.String("min")
+		if err != nil {
+			return nil, err
+		}
+		max, maxFound, err := p.String("max")
+		if err != nil {
+			return nil, err
+		}
+		if !minFound && !maxFound {
+			return nil, p.fail("min", "term range query requires min or max")
+		}
+
+		inclusiveMin, err := boolParam(p, "inclusive_min")
+		if err != nil {
+			return nil, err
+		}
+		inclusiveMax, err := boolParam(p, "inclusive_max")
+		if err != nil {
+			return nil, err
+		}
+
+		termRangeQuery := bleve.NewTermRangeInclusiveQuery(min, max, inclusiveMin, inclusiveMax)
+		if field != "" {
+			termRangeQuery.SetField(field)
+		}
+
+		return termRangeQuery, nil
+
+	case "Boolean":
+		must, err := parseQueryList(p, "must")
+		if err != nil {
+			return nil, err
+		}
+		should, err := parseQueryList(p, "should")
+		if err != nil {
+			return nil, err
+		}
+		mustNot, err := parseQueryList(p, "must_not")
+		if err != nil {
+			return nil, err
+		}
+
+		if len(must) == 0 && len(should) == 0 && len(mustNot) == 0 {
+			return nil, p.fail("must", "boolean query requires must, should or must_not")
+		}
+
+		booleanQuery := bleve.NewBooleanQuery()
+		booleanQuery.AddMust(must...)
+		booleanQuery.AddShould(should...)
+		booleanQuery.AddMustNot(mustNot...)
+
+		if minShould, found, err := p.Float64("min_should"); err != nil {
+			return nil, err
+		} else if found {
+			booleanQuery.SetMinShould(minShould)
+		}
+
+		return booleanQuery, nil
+
+	case "Conjunction":
+		queries, err := parseQueryList(p, "queries")
+		if err != nil {
+			return nil, err
+		}
+		if len(queries) == 0 {
+			return nil, p.fail("queries", "conjunction query requires at least one query")
+		}
+
+		return bleve.NewConjunctionQuery(queries...), nil
+
+	case "Disjunction":
+		queries, err := parseQueryList(p, "queries")
+		if err != nil {
+			return nil, err
+		}
+		if len(queries) == 0 {
+			return nil, p.fail("queries", "disjunction query requires at least one query")
+		}
+
+		disjunctionQuery := bleve.NewDisjunctionQuery(queries...)
+		if min, found, err := p.Float64("min"); err != nil {
+			return nil, err
+		} else if found {
+			disjunctionQuery.SetMin(min)
+		}
+
+		return disjunctionQuery, nil
+
+	case "Boost":
+		boost, boostFound, err := p.Float64("boost")
+		if err != nil {
+			return nil, err
+		}
+		if !boostFound {
+			return nil, p.fail("boost", "is required")
+		}
+
+		child, childFound, err := p.Map("query")
+		if err != nil {
+			return nil, err
+		}
+		if !childFound {
+			return nil, p.fail("query", "is required")
+		}
+
+		q, err := parseQuery(child, p.key("query."))
+		if err != nil {
+			return nil, err
+		}
+
+		boostableQuery, ok := q.(query.BoostableQuery)
+		if !ok {
+			return nil, p.fail("query", "query does not support boosting")
+		}
+		boostableQuery.SetBoost(boost)
+
+		return boostableQuery, nil
+	}
+	// Switch end
+
+	return nil, node.fail("name", fmt.Sprintf("unknown query `%s`", name))
+}
+
+// parseQueryList parses the list of child queries stored under key,
+// every child must be a valid query
+func parseQueryList(p *searchParams, key string) ([]query.Query, error) {
+	children, _, err := p.Maps(key)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]query.Query, 0, len(children))
+	for i, child := range children {
+		q, err := parseQuery(child, p.key(fmt.Sprintf("%s[%d].", key, i)))
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, q)
+	}
+
+	return output, nil
+}
+
+// boolParam returns a pointer to the boolean stored under key,
+// nil leaves the default of the query in place
+func boolParam(p *searchParams, key string) (*bool, error) {
+	v, found, err := p.Bool(key)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &v, nil
+}
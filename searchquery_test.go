@@ -0,0 +1,351 @@
+package dodod
+
+import (
+	"errors"
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search/query"
+	"testing"
+	"time"
+)
+
+func termQuery(term string) map[string]interface{} {
+	return map[string]interface{}{
+		"name": "Term",
+		"p": map[string]interface{}{
+			"term":  term,
+			"field": "id",
+		},
+	}
+}
+
+func TestParseQuery_Compound(t *testing.T) {
+	t.Helper()
+
+	t.Run("Boolean", func(t *testing.T) {
+		q, err := ParseQuery(map[string]interface{}{
+			"name": "Boolean",
+			"p": map[string]interface{}{
+				"must":       []interface{}{termQuery("1")},
+				"should":     []map[string]interface{}{termQuery("2"), termQuery("3")},
+				"must_not":   []interface{}{termQuery("4")},
+				"min_should": 1,
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := q.(*query.BooleanQuery); !ok {
+			t.Fatalf("unexpected query type: %T", q)
+		}
+	})
+
+	t.Run("Boolean without clauses", func(t *testing.T) {
+		if _, err := ParseQuery(map[string]interface{}{"name": "Boolean"}); !errors.Is(err, ErrInvalidSearchRequest) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Boolean with invalid child", func(t *testing.T) {
+		_, err := ParseQuery(map[string]interface{}{
+			"name": "Boolean",
+			"p": map[string]interface{}{
+				"must": []interface{}{map[string]interface{}{"name": "Unknown"}},
+			},
+		})
+		var requestError *SearchRequestError
+		if !errors.As(err, &requestError) || requestError.Key != "p.must[0].name" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Disjunction", func(t *testing.T) {
+		q, err := ParseQuery(map[string]interface{}{
+			"name": "Disjunction",
+			"p": map[string]interface{}{
+				"queries": []interface{}{termQuery("1"), termQuery("2")},
+				"min":     1.0,
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v, ok := q.(*query.DisjunctionQuery); !ok || v.Min != 1 {
+			t.Fatalf("unexpected query: %v", q)
+		}
+	})
+
+	t.Run("Conjunction without queries", func(t *testing.T) {
+		if _, err := ParseQuery(map[string]interface{}{"name": "Conjunction"}); !errors.Is(err, ErrInvalidSearchRequest) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Boost", func(t *testing.T) {
+		q, err := ParseQuery(map[string]interface{}{
+			"name": "Boost",
+			"p": map[string]interface{}{
+				"boost": 2,
+				"query": termQuery("1"),
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v, ok := q.(query.BoostableQuery); !ok || v.Boost() != 2 {
+			t.Fatalf("unexpected query: %v", q)
+		}
+	})
+
+	t.Run("Boost without boost", func(t *testing.T) {
+		_, err := ParseQuery(map[string]interface{}{
+			"name": "Boost",
+			"p": map[string]interface{}{
+				"query": termQuery("1"),
+			},
+		})
+		if !errors.Is(err, ErrInvalidSearchRequest) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestDatabase_CompoundSearch(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1", Name: "red apple"},
+		&MyTestDocument{Id: "2", Name: "green apple"},
+		&MyTestDocument{Id: "3", Name: "red cherry"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	matchName := func(match string) map[string]interface{} {
+		return map[string]interface{}{
+			"name": "Match",
+			"p": map[string]interface{}{
+				"match": match,
+				"field": "name",
+			},
+		}
+	}
+
+	input := map[string]interface{}{
+		"sort": []string{"_id"},
+		"query": map[string]interface{}{
+			"name": "Boolean",
+			"p": map[string]interface{}{
+				"must":     []interface{}{matchName("apple")},
+				"must_not": []interface{}{matchName("green")},
+			},
+		},
+	}
+
+	if data, err := db.Search(input, "bleveSearchResult"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else {
+		bleveSearchResult := data.(*bleve.SearchResult)
+		if bleveSearchResult.Total != 1 || bleveSearchResult.Hits[0].ID != "1" {
+			t.Fatalf("Total Expected 1, but found: %v", bleveSearchResult.Total)
+		}
+	}
+
+	input["query"] = map[string]interface{}{
+		"name": "Disjunction",
+		"p": map[string]interface{}{
+			"queries": []interface{}{matchName("cherry"), matchName("green")},
+		},
+	}
+
+	if data, err := db.Search(input, "bleveSearchResult"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else {
+		bleveSearchResult := data.(*bleve.SearchResult)
+		if bleveSearchResult.Total != 2 {
+			t.Fatalf("Total Expected 2, but found: %v", bleveSearchResult.Total)
+		}
+	}
+
+	input["query"] = map[string]interface{}{
+		"name": "Conjunction",
+		"p": map[string]interface{}{
+			"queries": []interface{}{matchName("red"), matchName("cherry")},
+		},
+	}
+
+	if data, err := db.Search(input, "bleveSearchResult"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else {
+		bleveSearchResult := data.(*bleve.SearchResult)
+		if bleveSearchResult.Total != 1 || bleveSearchResult.Hits[0].ID != "3" {
+			t.Fatalf("Total Expected 1, but found: %v", bleveSearchResult.Total)
+		}
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
+
+type RangeTestDocument struct {
+	Id        string    `json:"id"`
+	Code      string    `json:"code"`
+	Price     float64   `json:"price"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (r *RangeTestDocument) Type() string {
+	return "RangeTestDocument"
+}
+
+func (r *RangeTestDocument) GetId() string {
+	return r.Id
+}
+
+func TestParseQuery_Range(t *testing.T) {
+	t.Helper()
+
+	t.Run("NumericRange without bounds", func(t *testing.T) {
+		if _, err := ParseQuery(map[string]interface{}{"name": "NumericRange"}); !errors.Is(err, ErrInvalidSearchRequest) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("NumericRange with int and float64", func(t *testing.T) {
+		q, err := ParseQuery(map[string]interface{}{
+			"name": "NumericRange",
+			"p": map[string]interface{}{
+				"min":           10,
+				"max":           20.5,
+				"inclusive_max": true,
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		v, ok := q.(*query.NumericRangeQuery)
+		if !ok || *v.Min != 10 || *v.Max != 20.5 || !*v.InclusiveMax {
+			t.Fatalf("unexpected query: %v", q)
+		}
+	})
+
+	t.Run("DateRange with invalid start", func(t *testing.T) {
+		_, err := ParseQuery(map[string]interface{}{
+			"name": "DateRange",
+			"p": map[string]interface{}{
+				"start": "yesterday",
+			},
+		})
+		var requestError *SearchRequestError
+		if !errors.As(err, &requestError) || requestError.Key != "p.start" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("TermRange without bounds", func(t *testing.T) {
+		if _, err := ParseQuery(map[string]interface{}{"name": "TermRange"}); !errors.Is(err, ErrInvalidSearchRequest) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestDatabase_RangeSearch(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&RangeTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := db.Create([]interface{}{
+		&RangeTestDocument{Id: "1", Code: "apple", Price: 5, CreatedAt: base},
+		&RangeTestDocument{Id: "2", Code: "banana", Price: 15, CreatedAt: base.Add(24 * time.Hour)},
+		&RangeTestDocument{Id: "3", Code: "cherry", Price: 20, CreatedAt: base.Add(48 * time.Hour)},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	search := func(q map[string]interface{}) *bleve.SearchResult {
+		t.Helper()
+		data, err := db.Search(map[string]interface{}{"query": q}, "bleveSearchResult")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return data.(*bleve.SearchResult)
+	}
+
+	if v := search(map[string]interface{}{
+		"name": "NumericRange",
+		"p": map[string]interface{}{
+			"min":           10,
+			"max":           20.0,
+			"inclusive_max": true,
+			"field":         "price",
+		},
+	}); v.Total != 2 {
+		t.Fatalf("Total Expected 2, but found: %v", v.Total)
+	}
+
+	if v := search(map[string]interface{}{
+		"name": "NumericRange",
+		"p": map[string]interface{}{
+			"min":           10,
+			"max":           20.0,
+			"inclusive_max": false,
+			"field":         "price",
+		},
+	}); v.Total != 1 {
+		t.Fatalf("Total Expected 1, but found: %v", v.Total)
+	}
+
+	if v := search(map[string]interface{}{
+		"name": "DateRange",
+		"p": map[string]interface{}{
+			"start": base.Add(12 * time.Hour).Format(time.RFC3339),
+			"field": "created_at",
+		},
+	}); v.Total != 2 {
+		t.Fatalf("Total Expected 2, but found: %v", v.Total)
+	}
+
+	if v := search(map[string]interface{}{
+		"name": "TermRange",
+		"p": map[string]interface{}{
+			"min":   "apple",
+			"max":   "banana",
+			"field": "code",
+		},
+	}); v.Total != 1 {
+		t.Fatalf("Total Expected 1, but found: %v", v.Total)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
@@ -0,0 +1,479 @@
+package dodod
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search/query"
+)
+
+// DefaultSearchSize is the number of hits returned when size is not provided
+const DefaultSearchSize = 10
+
+// SearchRequestError reports the search request key which failed validation
+type SearchRequestError struct {
+	Key    string
+	Reason string
+}
+
+func (e *SearchRequestError) Error() string {
+	return fmt.Sprintf("dodod: invalid search request key `%s`: %s", e.Key, e.Reason)
+}
+
+func (e *SearchRequestError) Unwrap() error {
+	return ErrInvalidSearchRequest
+}
+
+// SearchHighlight defines highlighting of the matched fields
+type SearchHighlight struct {
+	Style  string
+	Fields []string
+}
+
+// SearchDateTimeRange is a named date time range bucket of a facet,
+// an empty Start or End leaves the range open on that side
+type SearchDateTimeRange struct {
+	Name  string
+	Start string
+	End   string
+}
+
+// SearchNumericRange is a named numeric range bucket of a facet,
+// a nil Min or Max leaves the range open on that side
+type SearchNumericRange struct {
+	Name string
+	Min  *float64
+	Max  *float64
+}
+
+// SearchFacet defines a facet to be computed over the search result
+type SearchFacet struct {
+	Name           string
+	Field          string
+	Size           int
+	DateTimeRanges []*SearchDateTimeRange
+	NumericRanges  []*SearchNumericRange
+}
+
+// SearchRequest is the typed form of the Search input map
+type SearchRequest struct {
+	Query            query.Query
+	Size             int
+	From             int
+	Fields           []string
+	Explain          bool
+	Sort             []string
+	IncludeLocations bool
+	Score            string
+	SearchAfter      []string
+	SearchBefore     []string
+	Highlight        *SearchHighlight
+	Facets           []*SearchFacet
+}
+
+// NewSearchRequest creates a search request matching
+// all documents with the default size
+func NewSearchRequest() *SearchRequest {
+	return &SearchRequest{Size: DefaultSearchSize}
+}
+
+// Validate checks the search request for invalid values
+func (r *SearchRequest) Validate() error {
+	if r.Size < 0 {
+		return &SearchRequestError{Key: "size", Reason: "must not be negative"}
+	}
+
+	if r.From < 0 {
+		return &SearchRequestError{Key: "from", Reason: "must not be negative"}
+	}
+
+	for _, v := range r.Sort {
+		if v == "" {
+			return &SearchRequestError{Key: "sort", Reason: "sort field can not be empty"}
+		}
+	}
+
+	if len(r.SearchAfter) > 0 && len(r.SearchBefore) > 0 {
+		return &SearchRequestError{Key: "search_after", Reason: "can not be used with search_before"}
+	}
+
+	names := make(map[string]bool)
+	for i, facet := range r.Facets {
+		key := fmt.Sprintf("facets[%d]", i)
+		if facet == nil {
+			return &SearchRequestError{Key: key, Reason: "facet can not be nil"}
+		}
+		if facet.Name == "" {
+			return &SearchRequestError{Key: key + ".name", Reason: "can not be empty"}
+		}
+		if names[facet.Name] {
+			return &SearchRequestError{Key: key + ".name", Reason: fmt.Sprintf("duplicate facet `%s`", facet.Name)}
+		}
+		names[facet.Name] = true
+
+		if facet.Field == "" {
+			return &SearchRequestError{Key: key + ".field", Reason: "can not be empty"}
+		}
+		if facet.Size < 0 {
+			return &SearchRequestError{Key: key + ".size", Reason: "must not be negative"}
+		}
+		for j, v := range facet.DateTimeRanges {
+			if v == nil || v.Name == "" {
+				return &SearchRequestError{Key: fmt.Sprintf("%s.date_time_range[%d].name", key, j), Reason: "can not be empty"}
+			}
+		}
+		for j, v := range facet.NumericRanges {
+			if v == nil || v.Name == "" {
+				return &SearchRequestError{Key: fmt.Sprintf("%s.numeric_range[%d].name", key, j), Reason: "can not be empty"}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ToBleveSearchRequest validates and converts the search request
+// to a bleve search request, a nil query matches all documents
+func (r *SearchRequest) ToBleveSearchRequest() (*bleve.SearchRequest, error) {
+	if r == nil {
+		return nil, ErrInvalidSearchRequest
+	}
+
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	bleveQuery := r.Query
+	if bleveQuery == nil {
+		bleveQuery = bleve.NewMatchAllQuery()
+	}
+
+	searchRequest := bleve.NewSearchRequest(bleveQuery)
+	searchRequest.Size = r.Size
+	searchRequest.From = r.From
+	searchRequest.Fields = r.Fields
+	searchRequest.Explain = r.Explain
+	searchRequest.IncludeLocations = r.IncludeLocations
+	searchRequest.Score = r.Score
+	searchRequest.SearchAfter = r.SearchAfter
+	searchRequest.SearchBefore = r.SearchBefore
+
+	if len(r.Sort) > 0 {
+		searchRequest.SortBy(r.Sort)
+	}
+
+	if r.Highlight != nil {
+		searchRequest.Highlight = bleve.NewHighlight()
+		if r.Highlight.Style != "" {
+			style := r.Highlight.Style
+			searchRequest.Highlight.Style = &style
+		}
+		searchRequest.Highlight.Fields = r.Highlight.Fields
+	}
+
+	for _, facet := range r.Facets {
+		facetRequest := bleve.NewFacetRequest(facet.Field, facet.Size)
+
+		for _, v := range facet.DateTimeRanges {
+			var start *string
+			var end *string
+			if v.Start != "" {
+				s := v.Start
+				start = &s
+			}
+			if v.End != "" {
+				e := v.End
+				end = &e
+			}
+			facetRequest.AddDateTimeRangeString(v.Name, start, end)
+		}
+
+		for _, v := range facet.NumericRanges {
+			facetRequest.AddNumericRange(v.Name, v.Min, v.Max)
+		}
+
+		searchRequest.AddFacet(facet.Name, facetRequest)
+	}
+
+	return searchRequest, nil
+}
+
+// NewSearchRequestFromMap converts the Search input map to a search request.
+//
+// Numbers may be provided as float64 and lists as []interface{}, so the
+// input can come straight from json.Unmarshal. A value of the wrong type
+// results in a SearchRequestError naming the offending key
+func NewSearchRequestFromMap(input map[string]interface{}) (*SearchRequest, error) {
+	request := NewSearchRequest()
+	p := newSearchParams(input, "")
+
+	if val, found, err := p.Map("query"); err != nil {
+		return nil, err
+	} else if found {
+		q, err := parseQuery(val, "query.")
+		if err != nil {
+			return nil, err
+		}
+		request.Query = q
+	}
+
+	if size, found, err := p.Int("size"); err != nil {
+		return nil, err
+	} else if found {
+		request.Size = size
+	}
+	if from, found, err := p.Int("from"); err != nil {
+		return nil, err
+	} else if found {
+		request.From = from
+	}
+	if fields, found, err := p.Strings("fields"); err != nil {
+		return nil, err
+	} else if found {
+		request.Fields = fields
+	}
+	if explain, found, err := p.Bool("explain"); err != nil {
+		return nil, err
+	} else if found {
+		request.Explain = explain
+	}
+	if sort, found, err := p.Strings("sort"); err != nil {
+		return nil, err
+	} else if found {
+		request.Sort = sort
+	}
+	if includeLocations, found, err := p.Bool("include_locations"); err != nil {
+		return nil, err
+	} else if found {
+		request.IncludeLocations = includeLocations
+	}
+	if score, found, err := p.String("score"); err != nil {
+		return nil, err
+	} else if found {
+		request.Score = score
+	}
+	if searchAfter, found, err := p.Strings("search_after"); err != nil {
+		return nil, err
+	} else if found {
+		request.SearchAfter = searchAfter
+	}
+	if searchBefore, found, err := p.Strings("search_before"); err != nil {
+		return nil, err
+	} else if found {
+		request.SearchBefore = searchBefore
+	}
+
+	if highlight, found, err := p.Map("highlight"); err != nil {
+		return nil, err
+	} else if found {
+		h := newSearchParams(highlight, "highlight.")
+		request.Highlight = &SearchHighlight{}
+		if style, _, err := h.String("style"); err != nil {
+			return nil, err
+		} else {
+			request.Highlight.Style = style
+		}
+		if fields, _, err := h.Strings("fields"); err != nil {
+			return nil, err
+		} else {
+			request.Highlight.Fields = fields
+		}
+	}
+
+	// facets section
+	facets, _, err := p.Maps("facets")
+	if err != nil {
+		return nil, err
+	}
+
+	for i, singleFacet := range facets {
+		f := newSearchParams(singleFacet, fmt.Sprintf("facets[%d].", i))
+		facet := &SearchFacet{}
+
+		if facet.Name, _, err = f.String("name"); err != nil {
+			return nil, err
+		}
+		if facet.Field, _, err = f.String("field"); err != nil {
+			return nil, err
+		}
+		if facet.Size, _, err = f.Int("size"); err != nil {
+			return nil, err
+		}
+
+		dateTimeRangeList, _, err := f.Maps("date_time_range")
+		if err != nil {
+			return nil, err
+		}
+		for j, dateTimeRange := range dateTimeRangeList {
+			r := newSearchParams(dateTimeRange, f.key(fmt.Sprintf("date_time_range[%d].", j)))
+			v := &SearchDateTimeRange{}
+			if v.Name, _, err = r.String("name"); err != nil {
+				return nil, err
+			}
+			if v.Start, _, err = r.String("start"); err != nil {
+				return nil, err
+			}
+			if v.End, _, err = r.String("end"); err != nil {
+				return nil, err
+			}
+			facet.DateTimeRanges = append(facet.DateTimeRanges, v)
+		}
+
+		numericRangeList, _, err := f.Maps("numeric_range")
+		if err != nil {
+			return nil, err
+		}
+		for j, numericRange := range numericRangeList {
+			r := newSearchParams(numericRange, f.key(fmt.Sprintf("numeric_range[%d].", j)))
+			v := &SearchNumericRange{}
+			if v.Name, _, err = r.String("name"); err != nil {
+				return nil, err
+			}
+			if min, found, err := r.Float64("min"); err != nil {
+				return nil, err
+			} else if found {
+				v.Min = &min
+			}
+			if max, found, err := r.Float64("max"); err != nil {
+				return nil, err
+			} else if found {
+				v.Max = &max
+			}
+			facet.NumericRanges = append(facet.NumericRanges, v)
+		}
+
+		request.Facets = append(request.Facets, facet)
+	}
+
+	return request, nil
+}
+
+// NewSearchRequestFromJSON converts the JSON encoded Search input to a search request
+func NewSearchRequestFromJSON(data []byte) (*SearchRequest, error) {
+	input := make(map[string]interface{})
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, ErrJSONParseFailed
+	}
+
+	return NewSearchRequestFromMap(input)
+}
+
+// SearchRequestBuilder builds a SearchRequest using a fluent interface,
+// the first error is kept and returned by Build
+type SearchRequestBuilder struct {
+	request *SearchRequest
+	err     error
+}
+
+// NewSearchRequestBuilder creates a builder of a match all search request
+func NewSearchRequestBuilder() *SearchRequestBuilder {
+	return &SearchRequestBuilder{request: NewSearchRequest()}
+}
+
+// Query sets the bleve query
+func (b *SearchRequestBuilder) Query(q query.Query) *SearchRequestBuilder {
+	b.request.Query = q
+	return b
+}
+
+// QueryMap sets the query using the Search query DSL
+func (b *SearchRequestBuilder) QueryMap(val map[string]interface{}) *SearchRequestBuilder {
+	q, err := parseQuery(val, "query.")
+	if err != nil {
+		b.setError(err)
+		return b
+	}
+
+	b.request.Query = q
+	return b
+}
+
+// Size sets the number of hits to return
+func (b *SearchRequestBuilder) Size(size int) *SearchRequestBuilder {
+	b.request.Size = size
+	return b
+}
+
+// From sets the offset of the first hit
+func (b *SearchRequestBuilder) From(from int) *SearchRequestBuilder {
+	b.request.From = from
+	return b
+}
+
+// Fields sets the stored fields to load into the hits
+func (b *SearchRequestBuilder) Fields(fields ...string) *SearchRequestBuilder {
+	b.request.Fields = fields
+	return b
+}
+
+// Explain enables score explanation
+func (b *SearchRequestBuilder) Explain(explain bool) *SearchRequestBuilder {
+	b.request.Explain = explain
+	return b
+}
+
+// Sort sets the sort order, prefix a field with - for descending order
+func (b *SearchRequestBuilder) Sort(sort ...string) *SearchRequestBuilder {
+	b.request.Sort = sort
+	return b
+}
+
+// IncludeLocations enables term locations in the hits
+func (b *SearchRequestBuilder) IncludeLocations(includeLocations bool) *SearchRequestBuilder {
+	b.request.IncludeLocations = includeLocations
+	return b
+}
+
+// Score sets the scoring mode
+func (b *SearchRequestBuilder) Score(score string) *SearchRequestBuilder {
+	b.request.Score = score
+	return b
+}
+
+// SearchAfter sets the sort values of the hit to page after
+func (b *SearchRequestBuilder) SearchAfter(searchAfter ...string) *SearchRequestBuilder {
+	b.request.SearchAfter = searchAfter
+	return b
+}
+
+// SearchBefore sets the sort values of the hit to page before
+func (b *SearchRequestBuilder) SearchBefore(searchBefore ...string) *SearchRequestBuilder {
+	b.request.SearchBefore = searchBefore
+	return b
+}
+
+// Highlight enables highlighting of the provided fields using the style
+func (b *SearchRequestBuilder) Highlight(style string, fields ...string) *SearchRequestBuilder {
+	b.request.Highlight = &SearchHighlight{Style: style, Fields: fields}
+	return b
+}
+
+// Facet adds a facet to the search request
+func (b *SearchRequestBuilder) Facet(facet *SearchFacet) *SearchRequestBuilder {
+	if facet == nil {
+		b.setError(&SearchRequestError{Key: "facets", Reason: "facet can not be nil"})
+		return b
+	}
+
+	b.request.Facets = append(b.request.Facets, facet)
+	return b
+}
+
+// Build validates and returns the search request
+func (b *SearchRequestBuilder) Build() (*SearchRequest, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if err := b.request.Validate(); err != nil {
+		return nil, err
+	}
+
+	return b.request, nil
+}
+
+func (b *SearchRequestBuilder) setError(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,245 @@
+package dodod
+
+import (
+	"errors"
+	"github.com/blevesearch/bleve"
+	"testing"
+)
+
+func TestSearchRequestBuilder(t *testing.T) {
+	t.Helper()
+
+	t.Run("Valid request", func(t *testing.T) {
+		request, err := NewSearchRequestBuilder().
+			Query(bleve.NewMatchQuery("test")).
+			Size(20).
+			From(10).
+			Fields("*").
+			Sort("-_score", "_id").
+			Highlight("html", "name").
+			Facet(&SearchFacet{Name: "types", Field: "type", Size: 5}).
+			Build()
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		searchRequest, err := request.ToBleveSearchRequest()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if searchRequest.Size != 20 || searchRequest.From != 10 {
+			t.Fatalf("paging mismatch: %d, %d", searchRequest.Size, searchRequest.From)
+		}
+
+		if len(searchRequest.Sort) != 2 {
+			t.Fatalf("sort mismatch: %v", searchRequest.Sort)
+		}
+
+		if _, found := searchRequest.Facets["types"]; !found {
+			t.Fatalf("facet not found")
+		}
+	})
+
+	t.Run("Default request", func(t *testing.T) {
+		request, err := NewSearchRequestBuilder().Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if request.Size != DefaultSearchSize {
+			t.Fatalf("default size mismatch: %d", request.Size)
+		}
+	})
+
+	t.Run("Invalid size", func(t *testing.T) {
+		_, err := NewSearchRequestBuilder().Size(-1).Build()
+		var requestError *SearchRequestError
+		if !errors.As(err, &requestError) || requestError.Key != "size" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !errors.Is(err, ErrInvalidSearchRequest) {
+			t.Fatalf("error should wrap ErrInvalidSearchRequest")
+		}
+	})
+
+	t.Run("Invalid facet", func(t *testing.T) {
+		_, err := NewSearchRequestBuilder().Facet(&SearchFacet{Name: "types", Size: 5}).Build()
+		var requestError *SearchRequestError
+		if !errors.As(err, &requestError) || requestError.Key != "facets[0].field" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Unknown query", func(t *testing.T) {
+		_, err := NewSearchRequestBuilder().QueryMap(map[string]interface{}{"name": "Unknown"}).Build()
+		var requestError *SearchRequestError
+		if !errors.As(err, &requestError) || requestError.Key != "query.name" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Search after and before", func(t *testing.T) {
+		_, err := NewSearchRequestBuilder().SearchAfter("1").SearchBefore("2").Build()
+		if !errors.Is(err, ErrInvalidSearchRequest) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestNewSearchRequestFromMap(t *testing.T) {
+	t.Helper()
+
+	input := map[string]interface{}{
+		"size":   5,
+		"from":   1,
+		"fields": []string{"*"},
+		"sort":   []string{"_id"},
+		"query": map[string]interface{}{
+			"name": "Term",
+			"p": map[string]interface{}{
+				"term":  "1",
+				"field": "id",
+			},
+		},
+		"facets": []interface{}{
+			map[string]interface{}{
+				"name":  "Field3",
+				"field": "field_3",
+				"size":  10,
+				"numeric_range": []interface{}{
+					map[string]interface{}{
+						"name": "Test",
+						"min":  1.0,
+					},
+				},
+			},
+		},
+	}
+
+	request, err := NewSearchRequestFromMap(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if request.Size != 5 || request.From != 1 {
+		t.Fatalf("paging mismatch: %d, %d", request.Size, request.From)
+	}
+
+	if request.Query == nil {
+		t.Fatalf("query should not be nil")
+	}
+
+	if len(request.Facets) != 1 || len(request.Facets[0].NumericRanges) != 1 {
+		t.Fatalf("facets mismatch")
+	}
+
+	if request.Facets[0].NumericRanges[0].Max != nil {
+		t.Fatalf("numeric range max should be open")
+	}
+}
+
+func TestDatabase_SearchWithRequest(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.SearchWithRequest(NewSearchRequest(), ""); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1", Name: "first"},
+		&MyTestDocument{Id: "2", Name: "second"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	request, err := NewSearchRequestBuilder().
+		QueryMap(map[string]interface{}{
+			"name": "Match",
+			"p": map[string]interface{}{
+				"match": "second",
+				"field": "name",
+			},
+		}).
+		Sort("_id").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if data, err := db.SearchWithRequest(request, "bleveSearchResult"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else {
+		bleveSearchResult := data.(*bleve.SearchResult)
+		if bleveSearchResult.Total != 1 {
+			t.Fatalf("Total Expected 1, but found: %v", bleveSearchResult.Total)
+		}
+	}
+
+	if _, err := db.SearchWithRequest(&SearchRequest{Size: -1}, ""); !errors.Is(err, ErrInvalidSearchRequest) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
+
+func TestNewSearchRequestFromMap_WrongType(t *testing.T) {
+	t.Helper()
+
+	testCases := []struct {
+		input map[string]interface{}
+		key   string
+	}{
+		{map[string]interface{}{"size": "10"}, "size"},
+		{map[string]interface{}{"size": 2.5}, "size"},
+		{map[string]interface{}{"from": true}, "from"},
+		{map[string]interface{}{"fields": "*"}, "fields"},
+		{map[string]interface{}{"include_locations": 1.0}, "include_locations"},
+		{map[string]interface{}{"score": 1.0}, "score"},
+		{map[string]interface{}{"search_after": []interface{}{1.0}}, "search_after[0]"},
+		{map[string]interface{}{"search_before": "a"}, "search_before"},
+		{map[string]interface{}{"highlight": "html"}, "highlight"},
+		{map[string]interface{}{"highlight": map[string]interface{}{"style": 1.0}}, "highlight.style"},
+		{map[string]interface{}{"highlight": map[string]interface{}{"fields": "name"}}, "highlight.fields"},
+		{map[string]interface{}{"facets": map[string]interface{}{}}, "facets"},
+		{map[string]interface{}{"facets": []interface{}{
+			map[string]interface{}{"name": "n", "field": "f", "numeric_range": []interface{}{
+				map[string]interface{}{"name": "r", "min": "1"},
+			}},
+		}}, "facets[0].numeric_range[0].min"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := NewSearchRequestFromMap(testCase.input)
+		var requestError *SearchRequestError
+		if !errors.As(err, &requestError) || requestError.Key != testCase.key {
+			t.Fatalf("expected error for key %s, found: %v", testCase.key, err)
+		}
+	}
+
+	// json decoded numbers and lists are accepted
+	request, err := NewSearchRequestFromMap(map[string]interface{}{
+		"size":   float64(3),
+		"fields": []interface{}{"name"},
+	})
+	if err != nil || request.Size != 3 || len(request.Fields) != 1 {
+		t.Fatalf("unexpected request %+v: %v", request, err)
+	}
+}
@@ -0,0 +1,356 @@
+package dodod
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dgraph-io/badger/v2"
+	"reflect"
+	"sort"
+)
+
+// secondaryIndexPrefix holds the entries of the non unique secondary
+// indexes as the escaped document type and field, the value and the id
+// separated by zero bytes
+var secondaryIndexPrefix = []byte("\x00dodod/index/")
+
+// uniqueIndexPrefix holds the entries of the unique secondary indexes as
+// the escaped document type and field and the value, the value of the
+// entry is the id
+var uniqueIndexPrefix = []byte("\x00dodod/unique/")
+
+// indexManifestPrefix holds the secondary index keys written for an id,
+// so that they can be removed without decoding the previous document
+var indexManifestPrefix = []byte("\x00dodod/indexed/")
+
+// UniqueConstraintError reports the id which already holds
+// the value of a unique field
+type UniqueConstraintError struct {
+	Field string
+	Value string
+	Id    string
+}
+
+func (e *UniqueConstraintError) Error() string {
+	return fmt.Sprintf("dodod: unique constraint violation, field `%s` with value %s is used by id `%s`",
+		e.Field, e.Value, e.Id)
+}
+
+func (e *UniqueConstraintError) Unwrap() error {
+	return ErrUniqueConstraintViolation
+}
+
+func encodeIndexValue(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// the document type is escaped the same way as in the document keys,
+// so that the entries of two types never share a prefix
+func secondaryIndexValuePrefix(docType string, field string, value string) []byte {
+	return []byte(string(secondaryIndexPrefix) + TypeNamespacedKey(docType, field) + "\x00" + value + "\x00")
+}
+
+func secondaryIndexKey(docType string, field string, value string, id string) []byte {
+	return append(secondaryIndexValuePrefix(docType, field, value), id...)
+}
+
+func uniqueIndexKey(docType string, field string, value string) []byte {
+	return []byte(string(uniqueIndexPrefix) + TypeNamespacedKey(docType, field) + "\x00" + value)
+}
+
+func indexManifestKey(id string) []byte {
+	return append(append([]byte{}, indexManifestPrefix...), id...)
+}
+
+// secondaryIndexTypes returns the registered document types declaring the
+// field as secondary index in name order along with the unique flag of
+// the field for every type
+func (db *Database) secondaryIndexTypes(field string) ([]string, map[string]bool) {
+	types := make([]string, 0)
+	unique := make(map[string]bool)
+	for docType, fields := range db.secondaryIndexRegistryCache {
+		for _, f := range fields {
+			if f.name == field {
+				types = append(types, docType)
+				unique[docType] = f.unique
+			}
+		}
+	}
+	sort.Strings(types)
+	return types, unique
+}
+
+// secondaryIndexEntry is a secondary index key and its value
+type secondaryIndexEntry struct {
+	key   []byte
+	value []byte
+}
+
+// secondaryIndexEntries returns the secondary index entries of the document,
+// zero values are not indexed. The unique values are checked against the
+// other ids before anything is written
+func (db *Database) secondaryIndexEntries(txn *badger.Txn, id string, d interface{}) ([]*secondaryIndexEntry, error) {
+	document, ok := d.(Document)
+	if !ok {
+		return nil, ErrInvalidDocument
+	}
+
+	fields := db.secondaryIndexRegistryCache[document.Type()]
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(d))
+	if v.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	entries := make([]*secondaryIndexEntry, 0, len(fields))
+	for _, field := range fields {
+		fieldValue := v.FieldByIndex(field.index)
+		if fieldValue.IsZero() {
+			continue
+		}
+
+		value, err := encodeIndexValue(fieldValue.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		if !field.unique {
+			entries = append(entries, &secondaryIndexEntry{key: secondaryIndexKey(document.Type(), field.name, value, id), value: []byte{}})
+			continue
+		}
+
+		key := uniqueIndexKey(document.Type(), field.name, value)
+		owner, err := uniqueIndexOwner(txn, key)
+		if err != nil {
+			return nil, err
+		}
+		// the value is free again once its owner expired
+		if owner != "" && owner != id {
+			if _, err := txn.Get([]byte(owner)); err == nil {
+				return nil, &UniqueConstraintError{Field: field.name, Value: value, Id: owner}
+			} else if err != badger.ErrKeyNotFound {
+				return nil, err
+			}
+		}
+
+		entries = append(entries, &secondaryIndexEntry{key: key, value: []byte(id)})
+	}
+
+	return entries, nil
+}
+
+// writeSecondaryIndexes adds the secondary index entries and the manifest of the id
+func (db *Database) writeSecondaryIndexes(txn *badger.Txn, id string, entries []*secondaryIndexEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if err := txn.Set(entry.key, entry.value); err != nil {
+			return err
+		}
+		keys = append(keys, string(entry.key))
+	}
+
+	manifest, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	return txn.Set(indexManifestKey(id), manifest)
+}
+
+// removeSecondaryIndexes deletes the secondary index entries written for the id
+func (db *Database) removeSecondaryIndexes(txn *badger.Txn, id string) error {
+	keys, err := db.ownedSecondaryIndexKeys(txn, id)
+	if err != nil || keys == nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := txn.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+
+	return txn.Delete(indexManifestKey(id))
+}
+
+// uniqueIndexOwner returns the id holding the unique index key,
+// an empty string if the key is not used
+func uniqueIndexOwner(txn *badger.Txn, key []byte) (string, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	owner, err := item.ValueCopy(nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(owner), nil
+}
+
+// ownedSecondaryIndexKeys returns the secondary index entries listed in the
+// manifest of the id without the unique values taken over by another id
+// after the id expired, nil if the id has no manifest
+func (db *Database) ownedSecondaryIndexKeys(txn *badger.Txn, id string) ([]string, error) {
+	keys, err := db.secondaryIndexKeys(txn, id)
+	if err != nil || keys == nil {
+		return keys, err
+	}
+
+	owned := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if bytes.HasPrefix([]byte(key), uniqueIndexPrefix) {
+			owner, err := uniqueIndexOwner(txn, []byte(key))
+			if err != nil {
+				return nil, err
+			}
+			if owner != id {
+				continue
+			}
+		}
+		owned = append(owned, key)
+	}
+
+	return owned, nil
+}
+
+// secondaryIndexKeys returns the secondary index entries listed in the
+// manifest of the id, nil if the id has no manifest
+func (db *Database) secondaryIndexKeys(txn *badger.Txn, id string) ([]string, error) {
+	item, err := txn.Get(indexManifestKey(id))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	manifest, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0)
+	if err := json.Unmarshal(manifest, &keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// FindBy reads the documents whose secondary index field is equal to
+// the value, the field must be declared using the dodod struct tag.
+// Every document type declaring the field is looked up, use FindByType
+// to look up a single document type
+func (db *Database) FindBy(field string, value interface{}) (uint64, []interface{}, error) {
+	return db.FindByContext(context.Background(), field, value)
+}
+
+// FindByContext is the context aware variant of FindBy
+func (db *Database) FindByContext(ctx context.Context, field string, value interface{}) (uint64, []interface{}, error) {
+	return db.findBy(ctx, "", field, value)
+}
+
+// FindByType reads the documents of the document type whose secondary
+// index field is equal to the value, an empty type is the same as FindBy
+func (db *Database) FindByType(docType string, field string, value interface{}) (uint64, []interface{}, error) {
+	return db.FindByTypeContext(context.Background(), docType, field, value)
+}
+
+// FindByTypeContext is the context aware variant of FindByType
+func (db *Database) FindByTypeContext(ctx context.Context, docType string, field string, value interface{}) (uint64, []interface{}, error) {
+	return db.findBy(ctx, docType, field, value)
+}
+
+// findBy looks up the field of the document type, every document type
+// declaring the field if the type is empty
+func (db *Database) findBy(ctx context.Context, docType string, field string, value interface{}) (uint64, []interface{}, error) {
+	if !db.IsDatabaseReady() {
+		return 0, nil, ErrDatabaseIsNotOpen
+	}
+
+	types, unique := db.secondaryIndexTypes(field)
+	if docType != "" {
+		if _, indexed := unique[docType]; !indexed {
+			return 0, nil, ErrFieldIsNotIndexed
+		}
+		types = []string{docType}
+	}
+	if len(types) == 0 {
+		return 0, nil, ErrFieldIsNotIndexed
+	}
+
+	encodedValue, err := encodeIndexValue(value)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	ids := make([]string, 0)
+	for _, t := range types {
+		if unique[t] {
+			id, err := uniqueIndexOwner(txn, uniqueIndexKey(t, field, encodedValue))
+			if err != nil {
+				return 0, nil, err
+			}
+			if id != "" {
+				ids = append(ids, id)
+			}
+			continue
+		}
+
+		prefix := secondaryIndexValuePrefix(t, field, encodedValue)
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		for it.Rewind(); it.Valid(); it.Next() {
+			ids = append(ids, string(it.Item().Key()[len(prefix):]))
+		}
+		it.Close()
+	}
+
+	output := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+
+		item, err := txn.Get([]byte(id))
+		if err == badger.ErrKeyNotFound {
+			continue
+		} else if err != nil {
+			return 0, nil, err
+		}
+
+		data, err := item.ValueCopy(nil)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		doc, err := db.DecodeDocument(data)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		output = append(output, doc)
+	}
+
+	return uint64(len(output)), output, nil
+}
@@ -0,0 +1,246 @@
+package dodod
+
+import (
+	"errors"
+	"github.com/dgraph-io/badger/v2"
+	"testing"
+)
+
+type IndexedTestDocument struct {
+	Id    string `json:"id"`
+	Email string `json:"email" dodod:"unique"`
+	City  string `json:"city" dodod:"index"`
+}
+
+func (m *IndexedTestDocument) Type() string {
+	return "IndexedTestDocument"
+}
+
+func (m *IndexedTestDocument) GetId() string {
+	return m.Id
+}
+
+type IndexedTestAccount struct {
+	Id    string `json:"id"`
+	Email string `json:"email" dodod:"unique"`
+	City  string `json:"city" dodod:"index"`
+}
+
+func (m *IndexedTestAccount) Type() string {
+	return "IndexedTestAccount"
+}
+
+func (m *IndexedTestAccount) GetId() string {
+	return m.Id
+}
+
+func TestDatabase_FindBy(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&IndexedTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, err := db.FindBy("city", "dhaka"); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{
+		&IndexedTestDocument{Id: "1", Email: "one@example.com", City: "dhaka"},
+		&IndexedTestDocument{Id: "2", Email: "two@example.com", City: "dhaka"},
+		&IndexedTestDocument{Id: "3", Email: "three@example.com", City: "paris"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if total, _, err := db.FindBy("city", "dhaka"); err != nil || total != 2 {
+		t.Fatalf("expected 2 documents, found: %d, error: %v", total, err)
+	}
+
+	if total, docs, err := db.FindBy("email", "three@example.com"); err != nil || total != 1 {
+		t.Fatalf("expected 1 document, found: %d, error: %v", total, err)
+	} else if docs[0].(*IndexedTestDocument).Id != "3" {
+		t.Fatalf("unexpected document: %v", docs[0])
+	}
+
+	t.Run("Unique violation", func(t *testing.T) {
+		err := db.Create([]interface{}{
+			&IndexedTestDocument{Id: "4", Email: "one@example.com"},
+		})
+
+		var uniqueError *UniqueConstraintError
+		if !errors.As(err, &uniqueError) || uniqueError.Id != "1" || uniqueError.Field != "email" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !errors.Is(err, ErrUniqueConstraintViolation) {
+			t.Fatalf("error should wrap ErrUniqueConstraintViolation")
+		}
+
+		if total, _, _ := db.Read([]string{"4"}); total != 0 {
+			t.Fatalf("document should not be created")
+		}
+	})
+
+	t.Run("Update moves index entries", func(t *testing.T) {
+		if err := db.Update([]interface{}{
+			&IndexedTestDocument{Id: "1", Email: "new@example.com", City: "paris"},
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if total, _, _ := db.FindBy("city", "dhaka"); total != 1 {
+			t.Fatalf("expected 1 document, found: %d", total)
+		}
+		if total, _, _ := db.FindBy("city", "paris"); total != 2 {
+			t.Fatalf("expected 2 documents, found: %d", total)
+		}
+		if total, _, _ := db.FindBy("email", "one@example.com"); total != 0 {
+			t.Fatalf("old unique value should be released, found: %d", total)
+		}
+
+		if err := db.Create([]interface{}{
+			&IndexedTestDocument{Id: "4", Email: "one@example.com"},
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Delete removes index entries", func(t *testing.T) {
+		if err := db.Delete([]interface{}{&IndexedTestDocument{Id: "3"}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if total, _, _ := db.FindBy("email", "three@example.com"); total != 0 {
+			t.Fatalf("expected 0 documents, found: %d", total)
+		}
+		if total, _, _ := db.FindBy("city", "paris"); total != 1 {
+			t.Fatalf("expected 1 document, found: %d", total)
+		}
+	})
+
+	t.Run("Failed write keeps index entries", func(t *testing.T) {
+		err := db.internalDb.Update(func(txn *badger.Txn) error {
+			err := db.putDocument(txn, "2", &IndexedTestDocument{Id: "2", Email: "one@example.com", City: "rome"})
+			if !errors.Is(err, ErrUniqueConstraintViolation) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// commit whatever the failed write left in the transaction
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if total, _, _ := db.FindBy("email", "two@example.com"); total != 1 {
+			t.Fatalf("expected 1 document, found: %d", total)
+		}
+		if total, _, _ := db.FindBy("city", "dhaka"); total != 1 {
+			t.Fatalf("expected 1 document, found: %d", total)
+		}
+	})
+
+	t.Run("Remove keeps unique values of other ids", func(t *testing.T) {
+		discard := errors.New("discard")
+		key := uniqueIndexKey("IndexedTestDocument", "email", `"one@example.com"`)
+
+		err := db.internalDb.Update(func(txn *badger.Txn) error {
+			if err := txn.Set(key, []byte("5")); err != nil {
+				return err
+			}
+
+			if err := db.removeDocument(txn, "4"); err != nil {
+				return err
+			}
+
+			if owner, err := uniqueIndexOwner(txn, key); err != nil || owner != "5" {
+				t.Fatalf("unique value of another id should be kept, found: %q, error: %v", owner, err)
+			}
+			return discard
+		})
+		if err != discard {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Field is not indexed", func(t *testing.T) {
+		if _, _, err := db.FindBy("id", "1"); err != ErrFieldIsNotIndexed {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
+
+func TestDatabase_FindByType(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+	db.SetTypeNamespacedKeys(true)
+
+	if err := db.RegisterDocument(&IndexedTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.RegisterDocument(&IndexedTestAccount{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the unique values of two types do not collide
+	if err := db.Create([]interface{}{
+		&IndexedTestDocument{Id: "1", Email: "one@example.com", City: "dhaka"},
+		&IndexedTestAccount{Id: "1", Email: "one@example.com", City: "dhaka"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if total, docs, err := db.FindByType("IndexedTestAccount", "email", "one@example.com"); err != nil || total != 1 {
+		t.Fatalf("expected 1 document, found: %d, error: %v", total, err)
+	} else if _, ok := docs[0].(*IndexedTestAccount); !ok {
+		t.Fatalf("unexpected document: %v", docs[0])
+	}
+
+	if total, docs, err := db.FindByType("IndexedTestDocument", "city", "dhaka"); err != nil || total != 1 {
+		t.Fatalf("expected 1 document, found: %d, error: %v", total, err)
+	} else if _, ok := docs[0].(*IndexedTestDocument); !ok {
+		t.Fatalf("unexpected document: %v", docs[0])
+	}
+
+	if total, _, err := db.FindBy("email", "one@example.com"); err != nil || total != 2 {
+		t.Fatalf("expected 2 documents, found: %d, error: %v", total, err)
+	}
+
+	if _, _, err := db.FindByType("MyTestDocument", "email", "one@example.com"); err != ErrFieldIsNotIndexed {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{
+		&IndexedTestAccount{Id: "2", Email: "one@example.com"},
+	}); !errors.Is(err, ErrUniqueConstraintViolation) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// TokenValidator reports if the bearer token of a request is allowed
+type TokenValidator func(token string) bool
+
+// BearerAuth rejects the requests without an `Authorization: Bearer <token>`
+// header accepted by the validator with 401 Unauthorized
+func BearerAuth(validate TokenValidator) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if !strings.HasPrefix(header, "Bearer ") || !validate(strings.TrimPrefix(header, "Bearer ")) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StaticTokens returns a validator accepting the tokens
+func StaticTokens(tokens ...string) TokenValidator {
+	return func(token string) bool {
+		allowed := false
+		for _, t := range tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				allowed = true
+			}
+		}
+		return allowed
+	}
+}
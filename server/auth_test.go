@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBearerAuth(t *testing.T) {
+	handler := BearerAuth(StaticTokens("first", "second"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for header, status := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"first":         http.StatusUnauthorized,
+		"Bearer third":  http.StatusUnauthorized,
+		"Bearer first":  http.StatusNoContent,
+		"Bearer second": http.StatusNoContent,
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/docs/Book/1", nil)
+		if header != "" {
+			request.Header.Set("Authorization", header)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != status {
+			t.Fatalf("unexpected status %d for `%s`", recorder.Code, header)
+		}
+	}
+}
+
+func TestServer_Use(t *testing.T) {
+	order := make([]string, 0)
+	s := NewServer(nil)
+	for _, name := range []string{"first", "second"} {
+		name := name
+		s.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				w.WriteHeader(http.StatusTeapot)
+			})
+		})
+	}
+
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs/Book/1", nil))
+	if recorder.Code != http.StatusTeapot || len(order) != 1 || order[0] != "first" {
+		t.Fatalf("middlewares should run in order, got %v", order)
+	}
+}
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/mkawserm/dodod"
+)
+
+const (
+	// BulkOpPut creates or replaces the document
+	BulkOpPut = "put"
+
+	// BulkOpDelete deletes the document
+	BulkOpDelete = "delete"
+)
+
+// ErrInvalidBulkLine is returned for a bulk line which can not be applied
+var ErrInvalidBulkLine = errors.New("server: invalid bulk line")
+
+// BulkLine is a line of the bulk request body, the data is
+// required by put and ignored by delete
+type BulkLine struct {
+	Op   string          `json:"op"`
+	Type string          `json:"type"`
+	Id   string          `json:"id"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// BulkLineError reports a bulk line which could not be applied
+type BulkLineError struct {
+	// Line is the line number starting from one
+	Line int
+
+	Err error
+}
+
+func (e *BulkLineError) Error() string {
+	return fmt.Sprintf("server: bulk line %d: %v", e.Line, e.Err)
+}
+
+func (e *BulkLineError) Unwrap() error {
+	return e.Err
+}
+
+// BulkItem reports the result of a bulk line
+type BulkItem struct {
+	// Line is the line number starting from one
+	Line int `json:"line"`
+
+	// Status is http.StatusOK for an applied line, http.StatusNotFound
+	// for a deleted document which did not exist, otherwise the status
+	// of Error
+	Status int `json:"status"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// BulkResponse reports the applied bulk lines
+type BulkResponse struct {
+	Put     int `json:"put"`
+	Deleted int `json:"deleted"`
+
+	// NotFound is the number of deleted documents which did not exist
+	NotFound int `json:"notFound"`
+
+	// Line is the first line of the chunk which could not be applied,
+	// the lines before it were applied. Zero if every line was applied
+	Line int `json:"line,omitempty"`
+
+	// Error is the reason the chunk starting at Line failed
+	Error string `json:"error,omitempty"`
+
+	// Items are the results of the lines up to the end of the failed chunk,
+	// the lines after it are not reported
+	Items []*BulkItem `json:"items"`
+}
+
+// applied records the results of the lines
+func (r *BulkResponse) applied(operations []*bulkOperation, status int, err error) {
+	for _, operation := range operations {
+		item := &BulkItem{Line: operation.line, Status: status}
+		if err != nil {
+			item.Error = err.Error()
+		}
+		r.Items = append(r.Items, item)
+	}
+}
+
+// bulkOperation is a decoded bulk line, the document of a put
+// is decoded up front while the document to delete is read
+// when its group is applied
+type bulkOperation struct {
+	line    int
+	op      string
+	docType string
+	id      string
+	doc     interface{}
+}
+
+// readBulk decodes every line of the body before anything is applied
+func (s *Server) readBulk(body io.Reader) ([]*bulkOperation, error) {
+	operations := make([]*bulkOperation, 0)
+	reader := bufio.NewReader(body)
+
+	line := 0
+	for {
+		data, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+
+		if len(data) > 0 {
+			line = line + 1
+		}
+
+		if len(bytes.TrimSpace(data)) > 0 {
+			operation, err := s.bulkOperation(data)
+			if err != nil {
+				return nil, &BulkLineError{Line: line, Err: err}
+			}
+
+			operation.line = line
+			operations = append(operations, operation)
+		}
+
+		if readErr == io.EOF {
+			return operations, nil
+		}
+	}
+}
+
+// bulkOperation decodes the line
+func (s *Server) bulkOperation(data []byte) (*bulkOperation, error) {
+	line := &BulkLine{}
+	if err := json.Unmarshal(data, line); err != nil {
+		return nil, ErrInvalidBulkLine
+	}
+
+	if line.Type == "" || line.Id == "" {
+		return nil, ErrInvalidBulkLine
+	}
+
+	switch line.Op {
+	case "", BulkOpPut:
+		if len(line.Data) == 0 {
+			return nil, ErrInvalidBulkLine
+		}
+
+		doc, err := s.decodeDocument(line.Type, line.Id, line.Data)
+		if err != nil {
+			return nil, err
+		}
+		return &bulkOperation{op: BulkOpPut, docType: line.Type, id: line.Id, doc: doc}, nil
+
+	case BulkOpDelete:
+		return &bulkOperation{op: BulkOpDelete, docType: line.Type, id: line.Id}, nil
+
+	default:
+		return nil, ErrInvalidBulkLine
+	}
+}
+
+// applyBulkGroup writes the consecutive operations of the same kind together,
+// the documents to delete are read first so earlier groups are taken into account.
+// The failed operations are returned along with the error
+func (s *Server) applyBulkGroup(ctx context.Context, operations []*bulkOperation, response *BulkResponse) ([]*bulkOperation, error) {
+	docs := make([]interface{}, 0, len(operations))
+
+	if operations[0].op == BulkOpPut {
+		for _, operation := range operations {
+			docs = append(docs, operation.doc)
+		}
+
+		return s.applyBulkPut(ctx, operations, docs, response)
+	}
+
+	notFound := 0
+	found := make(map[*bulkOperation]bool)
+	for _, operation := range operations {
+		doc, err := s.readDocument(operation.docType, operation.id)
+		if err == dodod.ErrDocumentNotFound {
+			notFound = notFound + 1
+			continue
+		} else if err != nil {
+			return operations, err
+		}
+		docs = append(docs, doc)
+		found[operation] = true
+	}
+
+	if len(docs) > 0 {
+		if err := s.store.Delete(docs); err != nil {
+			return operations, err
+		}
+	}
+
+	for _, operation := range operations {
+		item := &BulkItem{Line: operation.line, Status: http.StatusOK}
+		if !found[operation] {
+			item.Status = http.StatusNotFound
+		}
+		response.Items = append(response.Items, item)
+	}
+
+	response.Deleted = response.Deleted + len(docs)
+	response.NotFound = response.NotFound + notFound
+	return nil, nil
+}
+
+// applyBulkPut writes the documents in chunks when the store supports it,
+// the chunks written before a failed chunk are kept
+func (s *Server) applyBulkPut(ctx context.Context, operations []*bulkOperation, docs []interface{}, response *BulkResponse) ([]*bulkOperation, error) {
+	writer, ok := s.store.(bulkWriter)
+	if !ok {
+		if err := s.store.Update(docs); err != nil {
+			return operations, err
+		}
+
+		response.Put = response.Put + len(docs)
+		response.applied(operations, http.StatusOK, nil)
+		return nil, nil
+	}
+
+	result, err := writer.BulkWriteContext(ctx, docs, &dodod.BulkOptions{ChunkSize: s.bulkChunk})
+	if result == nil {
+		return operations, err
+	}
+
+	written := result.Resume()
+	if err == nil {
+		written = len(operations)
+	}
+
+	response.Put = response.Put + written
+	response.applied(operations[:written], http.StatusOK, nil)
+	if err == nil {
+		return nil, nil
+	}
+
+	failed := operations[written:]
+	for _, chunk := range result.Chunks {
+		if chunk.Err != nil {
+			failed = operations[chunk.Start:chunk.End]
+		}
+	}
+	return failed, err
+}
+
+// handleBulk applies the JSON Lines of the body in order, consecutive
+// lines of the same operation are written together and puts are written in
+// chunks. Every line is decoded before anything is written, an invalid line
+// rejects the whole request. A chunk failing to apply stops the request, the
+// response reports the lines applied before it along with the failed lines
+func (s *Server) handleBulk(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	operations, err := s.readBulk(r.Body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	response := &BulkResponse{Items: make([]*BulkItem, 0, len(operations))}
+	for start := 0; start < len(operations); {
+		end := start
+		for end < len(operations) && operations[end].op == operations[start].op {
+			end++
+		}
+
+		if failed, err := s.applyBulkGroup(r.Context(), operations[start:end], response); err != nil {
+			response.applied(failed, statusOf(err), err)
+			response.Line = failed[0].line
+			response.Error = err.Error()
+			writeJSON(w, statusOf(err), response)
+			return
+		}
+
+		start = end
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
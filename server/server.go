@@ -0,0 +1,333 @@
+// Package server exposes a dodod.Dodod implementation through a REST API.
+//
+// The routes are:
+//
+//	GET    /docs/{type}/{id}   read a document
+//	PUT    /docs/{type}/{id}   create or replace a document
+//	DELETE /docs/{type}/{id}   delete a document
+//	POST   /_search            search using the input map, `?output=mapIncludeData`
+//	                           includes the documents of the hits
+//	POST   /_bulk              apply the JSON Lines operations of the body
+//
+// Errors are reported as `{"error":"..."}` along with the status code.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/mkawserm/dodod"
+)
+
+// DefaultMaxBodyBytes is the default size limit of a request body
+const DefaultMaxBodyBytes = 32 << 20
+
+var (
+	// ErrInvalidPath is returned for a document path without type or id
+	ErrInvalidPath = errors.New("server: invalid document path")
+
+	// ErrInvalidBody is returned for a request body which is not valid json
+	ErrInvalidBody = errors.New("server: invalid request body")
+
+	// ErrInvalidOutputType is returned for an unsupported search output type
+	ErrInvalidOutputType = errors.New("server: invalid search output type")
+
+	// ErrBodyTooLarge is returned for a request body above the size limit
+	ErrBodyTooLarge = errors.New("server: request body too large")
+)
+
+// Middleware wraps the handler of every request, it is used to plug
+// authentication and authorization in front of the API
+type Middleware func(next http.Handler) http.Handler
+
+// documentKeyer is implemented by stores deriving the document key from
+// the document type and id, such as a dodod.Database
+type documentKeyer interface {
+	DocumentKey(docType string, id string) string
+}
+
+// bulkWriter is implemented by stores writing documents in chunks,
+// such as a dodod.Database
+type bulkWriter interface {
+	BulkWriteContext(ctx context.Context, data []interface{}, opts *dodod.BulkOptions) (*dodod.BulkResult, error)
+}
+
+// Server serves the REST API of a dodod.Dodod implementation
+type Server struct {
+	store        dodod.Dodod
+	middlewares  []Middleware
+	maxBodyBytes int64
+	bulkChunk    int
+	mux          *http.ServeMux
+}
+
+// NewServer returns the server of the store, the store must be open
+func NewServer(store dodod.Dodod) *Server {
+	s := &Server{store: store, maxBodyBytes: DefaultMaxBodyBytes, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("/docs/", s.handleDocument)
+	s.mux.HandleFunc("/_search", s.handleSearch)
+	s.mux.HandleFunc("/_bulk", s.handleBulk)
+
+	return s
+}
+
+// Use adds the middleware, middlewares run in the order they are added
+func (s *Server) Use(middleware Middleware) {
+	s.middlewares = append(s.middlewares, middleware)
+}
+
+// SetMaxBodyBytes sets the size limit of a request body
+func (s *Server) SetMaxBodyBytes(n int64) {
+	s.maxBodyBytes = n
+}
+
+// SetBulkChunkSize sets the number of documents written together by a bulk
+// request, zero means dodod.DefaultBulkChunkSize
+func (s *Server) SetBulkChunkSize(n int) {
+	s.bulkChunk = n
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var handler http.Handler = s.mux
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+
+	r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, s.maxBodyBytes), remaining: s.maxBodyBytes}
+	handler.ServeHTTP(w, r)
+}
+
+// limitedBody reports the error of http.MaxBytesReader as ErrBodyTooLarge,
+// the reader only fails once the limit is reached
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.remaining = b.remaining - int64(n)
+	if err != nil && err != io.EOF && b.remaining <= 0 {
+		err = ErrBodyTooLarge
+	}
+	return n, err
+}
+
+// documentKey returns the key of the document type and id in the store
+func (s *Server) documentKey(docType string, id string) string {
+	if keyer, ok := s.store.(documentKeyer); ok {
+		return keyer.DocumentKey(docType, id)
+	}
+	return id
+}
+
+// newDocument returns a new document of the registered type
+func (s *Server) newDocument(docType string) (interface{}, error) {
+	registered, ok := s.store.GetRegisteredDocument()[docType]
+	if !ok {
+		return nil, dodod.ErrDocumentTypeIsNotRegistered
+	}
+
+	return reflect.New(reflect.Indirect(reflect.ValueOf(registered)).Type()).Interface(), nil
+}
+
+// readDocument reads the document of the type and id
+func (s *Server) readDocument(docType string, id string) (interface{}, error) {
+	_, docs, err := s.store.Read([]string{s.documentKey(docType, id)})
+	if err != nil {
+		return nil, err
+	}
+
+	// with plain id keys the id may hold a document of another type
+	if len(docs) == 0 || docs[0].(dodod.Document).Type() != docType {
+		return nil, dodod.ErrDocumentNotFound
+	}
+
+	return docs[0], nil
+}
+
+// decodeDocument decodes the json data into a new document of the registered type
+func (s *Server) decodeDocument(docType string, id string, data []byte) (interface{}, error) {
+	doc, err := s.newDocument(docType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, ErrInvalidBody
+	}
+
+	document, ok := doc.(dodod.Document)
+	if !ok {
+		return nil, dodod.ErrInvalidDocument
+	}
+
+	if document.GetId() != id {
+		return nil, dodod.ErrIdCanNotBeChanged
+	}
+
+	return doc, nil
+}
+
+// documentPath splits `/docs/{type}/{id}` into its type and id
+func documentPath(u *url.URL) (string, string, error) {
+	path := u.EscapedPath()
+	parts := strings.Split(strings.TrimPrefix(path, "/docs/"), "/")
+	if len(parts) != 2 {
+		return "", "", ErrInvalidPath
+	}
+
+	docType, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return "", "", ErrInvalidPath
+	}
+
+	id, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return "", "", ErrInvalidPath
+	}
+
+	if docType == "" || id == "" {
+		return "", "", ErrInvalidPath
+	}
+
+	return docType, id, nil
+}
+
+func (s *Server) handleDocument(w http.ResponseWriter, r *http.Request) {
+	docType, id, err := documentPath(r.URL)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		doc, err := s.readDocument(docType, id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, doc)
+
+	case http.MethodPut:
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		doc, err := s.decodeDocument(docType, id, data)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if err := s.store.Update([]interface{}{doc}); err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, doc)
+
+	case http.MethodDelete:
+		doc, err := s.readDocument(docType, id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if err := s.store.Delete([]interface{}{doc}); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeMethodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	outputType := r.URL.Query().Get("output")
+	if outputType == "" {
+		outputType = "map"
+	}
+	if outputType != "map" && outputType != "mapIncludeData" {
+		writeError(w, ErrInvalidOutputType)
+		return
+	}
+
+	input := make(map[string]interface{})
+	if err := json.NewDecoder(r.Body).Decode(&input); errors.Is(err, ErrBodyTooLarge) {
+		writeError(w, err)
+		return
+	} else if err != nil {
+		writeError(w, ErrInvalidBody)
+		return
+	}
+
+	output, err := s.store.Search(input, outputType)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, output)
+}
+
+// statusOf maps an error to the status code of the response
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, dodod.ErrDocumentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidPath),
+		errors.Is(err, ErrInvalidBody),
+		errors.Is(err, ErrInvalidOutputType),
+		errors.Is(err, ErrInvalidBulkLine),
+		errors.Is(err, dodod.ErrDocumentTypeIsNotRegistered),
+		errors.Is(err, dodod.ErrInvalidDocument),
+		errors.Is(err, dodod.ErrIdCanNotBeEmpty),
+		errors.Is(err, dodod.ErrIdCanNotBeChanged),
+		errors.Is(err, dodod.ErrIdIsReserved),
+		errors.Is(err, dodod.ErrInvalidSearchRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, dodod.ErrUniqueConstraintViolation),
+		errors.Is(err, dodod.ErrVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, dodod.ErrDatabaseIsReadOnly):
+		return http.StatusForbidden
+	case errors.Is(err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, statusOf(err), map[string]string{"error": err.Error()})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+}
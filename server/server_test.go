@@ -0,0 +1,264 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/mkawserm/dodod"
+)
+
+type Book struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
+
+func (b *Book) Type() string {
+	return "Book"
+}
+
+func (b *Book) GetId() string {
+	return b.Id
+}
+
+func newTestStore(t *testing.T, path string) *dodod.Database {
+	t.Helper()
+
+	db := &dodod.Database{}
+	db.SetupDefaults()
+	db.SetDbPath(path)
+	db.SetTypeNamespacedKeys(true)
+
+	if err := db.RegisterDocument(&Book{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return db
+}
+
+func cleanupStore(t *testing.T, db *dodod.Database, path string) {
+	t.Helper()
+
+	_ = db.Close()
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	debug.FreeOSMemory()
+}
+
+func doRequest(t *testing.T, handler http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return recorder
+}
+
+func TestServer_Documents(t *testing.T) {
+	dbPath := "/tmp/dodod-server"
+	db := newTestStore(t, dbPath)
+	defer cleanupStore(t, db, dbPath)
+
+	s := NewServer(db)
+
+	if r := doRequest(t, s, http.MethodGet, "/docs/Book/1", ""); r.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+
+	if r := doRequest(t, s, http.MethodPut, "/docs/Book/1", `{"id":"1","title":"go in action"}`); r.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+
+	r := doRequest(t, s, http.MethodGet, "/docs/Book/1", "")
+	if r.Code != http.StatusOK || strings.TrimSpace(r.Body.String()) != `{"id":"1","title":"go in action"}` {
+		t.Fatalf("unexpected response %d: %s", r.Code, r.Body.String())
+	}
+
+	if r := doRequest(t, s, http.MethodPut, "/docs/Book/1", `{"id":"2"}`); r.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+	if r := doRequest(t, s, http.MethodPut, "/docs/Author/1", `{"id":"1"}`); r.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+	if r := doRequest(t, s, http.MethodPut, "/docs/Book/1", `not json`); r.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+	if r := doRequest(t, s, http.MethodGet, "/docs/Book", ""); r.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+	if r := doRequest(t, s, http.MethodPost, "/docs/Book/1", ""); r.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+
+	r = doRequest(t, s, http.MethodPost, "/_search", `{"query":{"name":"Match","p":{"match":"action"}}}`)
+	output := make(map[string]interface{})
+	if err := json.Unmarshal(r.Body.Bytes(), &output); err != nil || r.Code != http.StatusOK || output["total_hits"] != float64(1) {
+		t.Fatalf("unexpected search %d: %s", r.Code, r.Body.String())
+	}
+
+	r = doRequest(t, s, http.MethodPost, "/_search?output=mapIncludeData", `{"query":{"name":"Match","p":{"match":"action"}}}`)
+	if r.Code != http.StatusOK || !strings.Contains(r.Body.String(), `"title":"go in action"`) {
+		t.Fatalf("unexpected search %d: %s", r.Code, r.Body.String())
+	}
+
+	if r := doRequest(t, s, http.MethodPost, "/_search?output=bytes", `{}`); r.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+	if r := doRequest(t, s, http.MethodPost, "/_search", `{"query":{"name":"Unknown"}}`); r.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+
+	if r := doRequest(t, s, http.MethodDelete, "/docs/Book/1", ""); r.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+	if r := doRequest(t, s, http.MethodDelete, "/docs/Book/1", ""); r.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+
+	s.SetMaxBodyBytes(8)
+	if r := doRequest(t, s, http.MethodPut, "/docs/Book/1", `{"id":"1","title":"go in action"}`); r.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+}
+
+var errFailingStore = errors.New("failing store")
+
+// failingStore fails to update the documents along with the id
+type failingStore struct {
+	*dodod.Database
+	id string
+}
+
+func (f *failingStore) Update(data []interface{}) error {
+	for _, d := range data {
+		if d.(dodod.Document).GetId() == f.id {
+			return errFailingStore
+		}
+	}
+	return f.Database.Update(data)
+}
+
+// BulkWriteContext writes the documents before the id, the chunk of the
+// id fails along with the documents after it
+func (f *failingStore) BulkWriteContext(ctx context.Context, data []interface{}, opts *dodod.BulkOptions) (*dodod.BulkResult, error) {
+	for i, d := range data {
+		if d.(dodod.Document).GetId() == f.id {
+			result, err := f.Database.BulkWriteContext(ctx, data[:i], opts)
+			if err != nil {
+				return result, err
+			}
+			result.Chunks = append(result.Chunks, &dodod.BulkChunk{Start: i, End: len(data), Err: errFailingStore})
+			return result, errFailingStore
+		}
+	}
+	return f.Database.BulkWriteContext(ctx, data, opts)
+}
+
+func TestServer_Bulk(t *testing.T) {
+	dbPath := "/tmp/dodod-server"
+	db := newTestStore(t, dbPath)
+	defer cleanupStore(t, db, dbPath)
+
+	s := NewServer(db)
+
+	if err := db.Create([]interface{}{&Book{Id: "3", Title: "third"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body := strings.Join([]string{
+		`{"type":"Book","id":"1","data":{"id":"1","title":"first"}}`,
+		`{"op":"put","type":"Book","id":"2","data":{"id":"2","title":"second"}}`,
+		``,
+		`{"op":"delete","type":"Book","id":"3"}`,
+		`{"op":"delete","type":"Book","id":"4"}`,
+	}, "\n")
+
+	r := doRequest(t, s, http.MethodPost, "/_bulk", body)
+	if r.Code != http.StatusOK || strings.TrimSpace(r.Body.String()) != `{"put":2,"deleted":1,"notFound":1,"items":[`+
+		`{"line":1,"status":200},{"line":2,"status":200},{"line":4,"status":200},{"line":5,"status":404}]}` {
+		t.Fatalf("unexpected response %d: %s", r.Code, r.Body.String())
+	}
+
+	if total, _, _ := db.Read([]string{"Book:1", "Book:2", "Book:3"}); total != 2 {
+		t.Fatalf("bulk should write two documents, found %d", total)
+	}
+
+	invalid := strings.Join([]string{
+		`{"type":"Book","id":"5","data":{"id":"5"}}`,
+		`{"op":"rename","type":"Book","id":"1"}`,
+	}, "\n")
+
+	r = doRequest(t, s, http.MethodPost, "/_bulk", invalid)
+	if r.Code != http.StatusBadRequest || !strings.Contains(r.Body.String(), "bulk line 2") {
+		t.Fatalf("unexpected response %d: %s", r.Code, r.Body.String())
+	}
+	if db.IsDocumentExists("Book:5") {
+		t.Fatalf("invalid bulk request should not write anything")
+	}
+
+	// the document to delete is read after the put before it
+	sequence := strings.Join([]string{
+		`{"type":"Book","id":"6","data":{"id":"6"}}`,
+		`{"op":"delete","type":"Book","id":"6"}`,
+	}, "\n")
+
+	r = doRequest(t, s, http.MethodPost, "/_bulk", sequence)
+	if r.Code != http.StatusOK || strings.TrimSpace(r.Body.String()) != `{"put":1,"deleted":1,"notFound":0,"items":[`+
+		`{"line":1,"status":200},{"line":2,"status":200}]}` {
+		t.Fatalf("unexpected response %d: %s", r.Code, r.Body.String())
+	}
+	if db.IsDocumentExists("Book:6") {
+		t.Fatalf("document put before its delete should be deleted")
+	}
+
+	failing := NewServer(&failingStore{Database: db, id: "10"})
+	failing.SetBulkChunkSize(2)
+	partial := strings.Join([]string{
+		`{"type":"Book","id":"7","data":{"id":"7"}}`,
+		`{"op":"delete","type":"Book","id":"1"}`,
+		`{"type":"Book","id":"8","data":{"id":"8"}}`,
+		`{"type":"Book","id":"9","data":{"id":"9"}}`,
+		`{"type":"Book","id":"10","data":{"id":"10"}}`,
+		`{"type":"Book","id":"11","data":{"id":"11"}}`,
+		`{"op":"delete","type":"Book","id":"2"}`,
+	}, "\n")
+
+	r = doRequest(t, failing, http.MethodPost, "/_bulk", partial)
+	response := &BulkResponse{}
+	if err := json.Unmarshal(r.Body.Bytes(), response); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Code != http.StatusInternalServerError || response.Put != 3 || response.Deleted != 1 ||
+		response.Line != 5 || response.Error != errFailingStore.Error() || len(response.Items) != 6 {
+		t.Fatalf("unexpected response %d: %s", r.Code, r.Body.String())
+	}
+	for i, item := range response.Items {
+		if item.Line != i+1 {
+			t.Fatalf("unexpected item %d: %+v", i, item)
+		}
+		if i < 4 && (item.Status != http.StatusOK || item.Error != "") {
+			t.Fatalf("line %d should be applied: %+v", item.Line, item)
+		}
+		if i >= 4 && (item.Status != http.StatusInternalServerError || item.Error != errFailingStore.Error()) {
+			t.Fatalf("line %d should fail: %+v", item.Line, item)
+		}
+	}
+	if !db.IsDocumentExists("Book:7") || db.IsDocumentExists("Book:1") || !db.IsDocumentExists("Book:9") ||
+		db.IsDocumentExists("Book:10") || db.IsDocumentExists("Book:11") || !db.IsDocumentExists("Book:2") {
+		t.Fatalf("only the chunks before the failed chunk should be applied")
+	}
+
+	if r := doRequest(t, s, http.MethodGet, "/_bulk", ""); r.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status %d: %s", r.Code, r.Body.String())
+	}
+}
@@ -0,0 +1,254 @@
+package dodod
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/dgraph-io/badger/v2"
+	"time"
+)
+
+// ExpiringDocument is implemented by documents which expire,
+// a zero time means the document does not expire.
+//
+// The database drops an expired document on its own with a resolution of
+// one second, its index store and secondary index entries are removed by
+// SweepExpired or by the expiry sweeper
+type ExpiringDocument interface {
+	ExpiresAt() time.Time
+}
+
+// expiryPrefix holds a record for every expiring document
+// ordered by expiry time, it is used to find the expired documents
+var expiryPrefix = []byte("\x00dodod/expiry/")
+
+// DefaultExpirySweepInterval is the suggested interval of the background
+// sweeper for databases storing expiring documents
+const DefaultExpirySweepInterval = time.Minute
+
+// sweepBatchSize is the number of expiry records processed per transaction
+const sweepBatchSize = 1000
+
+// entrySetter is implemented by badger transactions and write batches
+type entrySetter interface {
+	SetEntry(e *badger.Entry) error
+}
+
+// now returns the current time of the database clock
+func (db *Database) now() time.Time {
+	if db.clock != nil {
+		return db.clock()
+	}
+	return time.Now()
+}
+
+func expiryKey(at time.Time, key string) []byte {
+	output := make([]byte, len(expiryPrefix)+8+len(key))
+	copy(output, expiryPrefix)
+	binary.BigEndian.PutUint64(output[len(expiryPrefix):], uint64(at.UnixNano()))
+	copy(output[len(expiryPrefix)+8:], key)
+	return output
+}
+
+// documentExpiry returns the expiry time of the document, zero if it does not expire
+func documentExpiry(d interface{}) time.Time {
+	if n, ok := d.(ExpiringDocument); ok {
+		return n.ExpiresAt()
+	}
+	return time.Time{}
+}
+
+// setDocumentEntry stores the encoded document under the key along with its
+// time to live and the expiry record of an expiring document, created tells
+// the change feed that the key held no document
+func (db *Database) setDocumentEntry(w entrySetter, key string, encoded []byte, d interface{}, created bool) error {
+	entry := badger.NewEntry([]byte(key), encoded)
+	if created {
+		entry = entry.WithMeta(createdMeta)
+	}
+
+	expiresAt := documentExpiry(d)
+	if expiresAt.IsZero() {
+		return w.SetEntry(entry)
+	}
+
+	if !expiresAt.After(db.now()) {
+		return ErrDocumentIsExpired
+	}
+
+	if err := w.SetEntry(badger.NewEntry(expiryKey(expiresAt, key), []byte{})); err != nil {
+		return err
+	}
+
+	entry.ExpiresAt = uint64(expiresAt.Unix())
+	return w.SetEntry(entry)
+}
+
+// SetExpirySweepInterval sets the interval of the background sweeper which
+// removes expired documents from the index store, zero disables the sweeper.
+// The sweeper is disabled by default, once enabled it runs while the database
+// is open and not read only
+func (db *Database) SetExpirySweepInterval(interval time.Duration) {
+	db.expirySweepInterval = interval
+}
+
+// SweepExpired removes the expired documents from the index store and
+// their secondary index entries, it returns the number of documents removed
+// from the index store. Documents deleted before they expired are not counted
+func (db *Database) SweepExpired() (uint64, error) {
+	return db.SweepExpiredContext(context.Background())
+}
+
+// SweepExpiredContext is the context aware variant of SweepExpired
+func (db *Database) SweepExpiredContext(ctx context.Context) (uint64, error) {
+	if !db.IsDatabaseReady() {
+		return 0, ErrDatabaseIsNotOpen
+	}
+
+	if db.isReadOnly {
+		return 0, ErrDatabaseIsReadOnly
+	}
+
+	var total uint64
+	for {
+		n, more, err := db.sweepExpiredBatch(ctx, db.now())
+		total = total + n
+		if err != nil || !more {
+			return total, err
+		}
+	}
+}
+
+// sweepExpiredBatch processes the expiry records up to the time, a record
+// whose document still exists was superseded by a later write and a record
+// whose document is not indexed belongs to a deleted document
+func (db *Database) sweepExpiredBatch(ctx context.Context, now time.Time) (uint64, bool, error) {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
+	txn := db.internalDb.NewTransaction(true)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = expiryPrefix
+	it := txn.NewIterator(opts)
+
+	records := make([][]byte, 0)
+	more := false
+	for it.Rewind(); it.Valid(); it.Next() {
+		key := it.Item().Key()
+		if binary.BigEndian.Uint64(key[len(expiryPrefix):]) > uint64(now.UnixNano()) {
+			break
+		}
+		if len(records) >= sweepBatchSize {
+			more = true
+			break
+		}
+		records = append(records, it.Item().KeyCopy(nil))
+	}
+	it.Close()
+
+	if len(records) == 0 {
+		return 0, false, nil
+	}
+
+	batch := db.internalIndex.NewBatch()
+	expired := make([]string, 0)
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return 0, false, err
+		}
+
+		key := string(record[len(expiryPrefix)+8:])
+		if _, err := txn.Get([]byte(key)); err == badger.ErrKeyNotFound {
+			if err := db.removeDocument(txn, key); err != nil {
+				return 0, false, err
+			}
+
+			indexed, err := db.internalIndex.Document(key)
+			if err != nil {
+				return 0, false, err
+			}
+			if indexed != nil {
+				batch.Delete(key)
+				expired = append(expired, key)
+			}
+		} else if err != nil {
+			return 0, false, err
+		}
+
+		if err := txn.Delete(record); err != nil {
+			return 0, false, err
+		}
+	}
+
+	var intentKey []byte
+	if len(expired) > 0 {
+		var err error
+		if intentKey, err = db.writeIndexIntent(txn, expired); err != nil {
+			return 0, false, err
+		}
+	}
+
+	if err := txn.Commit(); err != nil {
+		return 0, false, ErrDatabaseTransactionFailed
+	}
+
+	if len(expired) == 0 {
+		return 0, more, nil
+	}
+
+	// the intent stays in the database on failure and
+	// will be replayed by ReplayIntentLog
+	if err := db.internalIndex.Batch(batch); err != nil {
+		return 0, false, ErrIndexStoreTransactionFailed
+	}
+
+	db.clearIndexIntent(intentKey)
+
+	return uint64(len(expired)), more, nil
+}
+
+// startExpirySweeper runs SweepExpired in the background until stopExpirySweeper
+func (db *Database) startExpirySweeper() {
+	if db.expirySweepInterval <= 0 || db.isReadOnly {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	db.sweeperStop = stop
+	db.sweeperDone = done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(db.expirySweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if n, err := db.SweepExpired(); err != nil {
+					DefaultLogger.Warningf("failed to sweep expired documents: %v", err)
+				} else if n > 0 {
+					DefaultLogger.Infof("swept %d expired documents", n)
+				}
+			}
+		}
+	}()
+}
+
+// stopExpirySweeper stops the background sweeper and waits for it to finish
+func (db *Database) stopExpirySweeper() {
+	if db.sweeperStop == nil {
+		return
+	}
+
+	close(db.sweeperStop)
+	<-db.sweeperDone
+	db.sweeperStop = nil
+	db.sweeperDone = nil
+}
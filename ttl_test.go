@@ -0,0 +1,239 @@
+package dodod
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type SessionTestDocument struct {
+	Id      string    `json:"id"`
+	User    string    `json:"user"`
+	Expires time.Time `json:"expires"`
+}
+
+func (s *SessionTestDocument) Type() string {
+	return "SessionTestDocument"
+}
+
+func (s *SessionTestDocument) GetId() string {
+	return s.Id
+}
+
+func (s *SessionTestDocument) ExpiresAt() time.Time {
+	return s.Expires
+}
+
+type UniqueSessionTestDocument struct {
+	Id      string    `json:"id"`
+	Email   string    `json:"email" dodod:"unique"`
+	Expires time.Time `json:"expires"`
+}
+
+func (s *UniqueSessionTestDocument) Type() string {
+	return "UniqueSessionTestDocument"
+}
+
+func (s *UniqueSessionTestDocument) GetId() string {
+	return s.Id
+}
+
+func (s *UniqueSessionTestDocument) ExpiresAt() time.Time {
+	return s.Expires
+}
+
+// testClock is a database clock which is moved by the test
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+func TestDatabase_SweepExpired(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	// documents written an hour ago are expired by the database right away
+	clock := &testClock{now: time.Now().Add(-time.Hour)}
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+	db.clock = clock.Now
+
+	if err := db.RegisterDocument(&SessionTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.SweepExpired(); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.Create([]interface{}{
+		&SessionTestDocument{Id: "old", User: "someone", Expires: clock.Now().Add(-time.Second)},
+	}); err != ErrDocumentIsExpired {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	soon := clock.Now().Add(time.Second)
+	if err := db.Create([]interface{}{
+		&SessionTestDocument{Id: "1", User: "someone", Expires: soon},
+		&SessionTestDocument{Id: "2", User: "someone", Expires: soon},
+		&SessionTestDocument{Id: "3", User: "someone"},
+		&SessionTestDocument{Id: "4", User: "someone", Expires: time.Now().Add(time.Hour)},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// extending the expiry supersedes the first expiry record
+	if err := db.Update([]interface{}{
+		&SessionTestDocument{Id: "2", User: "someone", Expires: time.Now().Add(3 * time.Hour)},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a deleted document is not counted once its expiry record is swept
+	if err := db.Delete([]interface{}{&SessionTestDocument{Id: "4"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n, err := db.SweepExpired(); err != nil || n != 0 {
+		t.Fatalf("nothing should be expired yet: %d %v", n, err)
+	}
+
+	if db.IsDocumentExists("1") {
+		t.Fatalf("expired document should not be readable")
+	}
+	if !db.IsIndexExists("1") {
+		t.Fatalf("expired document should be indexed until it is swept")
+	}
+
+	clock.Set(time.Now().Add(2 * time.Hour))
+
+	n, err := db.SweepExpired()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("one document should be swept, found %d", n)
+	}
+
+	if db.IsIndexExists("1") {
+		t.Fatalf("expired document should be removed from the index store")
+	}
+	if !db.IsDocumentExists("2") || !db.IsIndexExists("2") || !db.IsDocumentExists("3") {
+		t.Fatalf("other documents should be kept")
+	}
+
+	if n, _ := db.SweepExpired(); n != 0 {
+		t.Fatalf("swept records should be removed, found %d", n)
+	}
+}
+
+func TestDatabase_ExpirySweeper(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	clock := &testClock{now: time.Now().Add(-time.Hour)}
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+	db.SetExpirySweepInterval(10 * time.Millisecond)
+	db.clock = clock.Now
+
+	if err := db.RegisterDocument(&SessionTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{
+		&SessionTestDocument{Id: "1", User: "someone", Expires: clock.Now().Add(time.Second)},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Set(time.Now())
+
+	// wait for the sweeper to run
+	deadline := time.Now().Add(5 * time.Second)
+	for db.IsIndexExists("1") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if db.IsIndexExists("1") {
+		t.Fatalf("sweeper should remove the expired document from the index store")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDatabase_SweepExpiredKeepsTakenUniqueValues(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	clock := &testClock{now: time.Now().Add(-time.Hour)}
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+	db.clock = clock.Now
+
+	if err := db.RegisterDocument(&UniqueSessionTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.Create([]interface{}{
+		&UniqueSessionTestDocument{Id: "x", Email: "e", Expires: clock.Now().Add(time.Second)},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Set(time.Now())
+
+	// the value of the expired document is free again
+	if err := db.Create([]interface{}{&UniqueSessionTestDocument{Id: "y", Email: "e"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n, err := db.SweepExpired(); err != nil || n != 1 {
+		t.Fatalf("one document should be swept: %d %v", n, err)
+	}
+
+	total, docs, err := db.FindBy("email", "e")
+	if err != nil || total != 1 || docs[0].(*UniqueSessionTestDocument).Id != "y" {
+		t.Fatalf("sweeping should keep the value taken over, found: %d, error: %v", total, err)
+	}
+}
@@ -0,0 +1,185 @@
+package dodod
+
+import (
+	"context"
+	"sort"
+)
+
+// VerifyOptions controls the consistency check done by Verify
+type VerifyOptions struct {
+	// Repair removes the orphaned index entries and indexes
+	// the unindexed documents after the check
+	Repair bool
+}
+
+// VerifyReport describes the drift between the database and the index store
+type VerifyReport struct {
+	// Documents is the number of records checked in the database
+	Documents uint64
+
+	// IndexEntries is the number of documents checked in the index store
+	IndexEntries uint64
+
+	// OrphanedIndexIds are indexed ids without a document in the database
+	OrphanedIndexIds []string
+
+	// UnindexedIds are ids of decodable documents missing from the index store
+	UnindexedIds []string
+
+	// UndecodableIds are ids of records which can not be decoded,
+	// documents of types which are not registered are decoded as raw documents
+	UndecodableIds []string
+
+	// Repaired reports if the orphaned and unindexed ids were repaired
+	Repaired bool
+}
+
+// IsConsistent reports if no drift was found
+func (r *VerifyReport) IsConsistent() bool {
+	return len(r.OrphanedIndexIds) == 0 && len(r.UnindexedIds) == 0 && len(r.UndecodableIds) == 0
+}
+
+// Verify walks the database and the index store and reports the ids which
+// exist in only one of them along with the records which can not be decoded.
+// Undecodable records are reported but never repaired, documents written
+// while the check runs may be reported as drift
+func (db *Database) Verify(opts *VerifyOptions) (*VerifyReport, error) {
+	return db.VerifyContext(context.Background(), opts)
+}
+
+// VerifyContext is the context aware variant of Verify
+func (db *Database) VerifyContext(ctx context.Context, opts *VerifyOptions) (*VerifyReport, error) {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
+	if !db.IsDatabaseReady() {
+		return nil, ErrDatabaseIsNotOpen
+	}
+
+	if opts == nil {
+		opts = &VerifyOptions{}
+	}
+
+	if opts.Repair && db.isReadOnly {
+		return nil, ErrDatabaseIsReadOnly
+	}
+
+	indexedIds, err := db.indexedIds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{
+		IndexEntries:     uint64(len(indexedIds)),
+		OrphanedIndexIds: make([]string, 0),
+		UnindexedIds:     make([]string, 0),
+		UndecodableIds:   make([]string, 0),
+	}
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	unindexed := make([]interface{}, 0)
+	_, err = db.iterateRaw(ctx, txn, "", nil, func(key []byte, value []byte) error {
+		id := string(key)
+		report.Documents = report.Documents + 1
+
+		_, indexed := indexedIds[id]
+		delete(indexedIds, id)
+
+		doc, err := db.decodeIndexValue(id, value)
+		if err != nil {
+			report.UndecodableIds = append(report.UndecodableIds, id)
+			return nil
+		}
+
+		if !indexed {
+			report.UnindexedIds = append(report.UnindexedIds, id)
+			unindexed = append(unindexed, doc)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// every remaining index id has no record in the database
+	for id := range indexedIds {
+		report.OrphanedIndexIds = append(report.OrphanedIndexIds, id)
+	}
+	sort.Strings(report.OrphanedIndexIds)
+
+	if !opts.Repair || (len(report.OrphanedIndexIds) == 0 && len(report.UnindexedIds) == 0) {
+		return report, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	batch := db.internalIndex.NewBatch()
+	for _, id := range report.OrphanedIndexIds {
+		batch.Delete(id)
+	}
+	for i, id := range report.UnindexedIds {
+		if err := batch.Index(id, unindexed[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := db.internalIndex.Batch(batch); err != nil {
+		return nil, ErrIndexStoreTransactionFailed
+	}
+
+	report.Repaired = true
+
+	return report, nil
+}
+
+// indexedIds returns the set of document ids stored in the index store
+func (db *Database) indexedIds(ctx context.Context) (map[string]struct{}, error) {
+	advancedIndex, _, err := db.internalIndex.Advanced()
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := advancedIndex.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	docIdReader, err := reader.DocIDReaderAll()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = docIdReader.Close()
+	}()
+
+	ids := make(map[string]struct{})
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		internalId, err := docIdReader.Next()
+		if err != nil {
+			return nil, err
+		}
+		if internalId == nil {
+			break
+		}
+
+		id, err := reader.ExternalID(internalId)
+		if err != nil {
+			return nil, err
+		}
+		ids[id] = struct{}{}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,91 @@
+package dodod
+
+import (
+	"github.com/dgraph-io/badger/v2"
+	"testing"
+)
+
+func TestDatabase_Verify(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.Verify(nil); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{
+		&MyTestDocument{Id: "1"},
+		&MyTestDocument{Id: "2"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report, err := db.Verify(nil); err != nil || !report.IsConsistent() {
+		t.Fatalf("expected consistent report, found: %+v, error: %v", report, err)
+	}
+
+	if err := db.CreateIndex([]interface{}{&MyTestDocument{Id: "orphan"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.CreateDocument([]interface{}{&MyTestDocument{Id: "unindexed"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the record is not an encoded document so it can not be decoded
+	if err := db.GetInternalDatabase().Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("undecodable"), []byte("invalid"))
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, err := db.Verify(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Documents != 4 || report.IndexEntries != 3 {
+		t.Fatalf("unexpected counts: %+v", report)
+	}
+	if len(report.OrphanedIndexIds) != 1 || report.OrphanedIndexIds[0] != "orphan" {
+		t.Fatalf("unexpected orphaned ids: %v", report.OrphanedIndexIds)
+	}
+	if len(report.UnindexedIds) != 1 || report.UnindexedIds[0] != "unindexed" {
+		t.Fatalf("unexpected unindexed ids: %v", report.UnindexedIds)
+	}
+	if len(report.UndecodableIds) != 1 || report.UndecodableIds[0] != "undecodable" {
+		t.Fatalf("unexpected undecodable ids: %v", report.UndecodableIds)
+	}
+	if report.Repaired {
+		t.Fatalf("report should not be repaired")
+	}
+
+	if report, err := db.Verify(&VerifyOptions{Repair: true}); err != nil || !report.Repaired {
+		t.Fatalf("expected repaired report, found: %+v, error: %v", report, err)
+	}
+
+	report, err = db.Verify(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.OrphanedIndexIds) != 0 || len(report.UnindexedIds) != 0 || len(report.UndecodableIds) != 1 {
+		t.Fatalf("unexpected report after repair: %+v", report)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
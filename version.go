@@ -0,0 +1,275 @@
+package dodod
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/dgraph-io/badger/v2"
+)
+
+// VersionedDocument is a document read along with its stored version
+type VersionedDocument struct {
+	Key      string
+	Version  uint64
+	Document interface{}
+}
+
+// VersionConflictError reports a document whose stored version
+// differs from the version expected by UpdateIfVersion
+type VersionConflictError struct {
+	Key      string
+	Expected uint64
+	Actual   uint64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("dodod: version conflict for `%s`, expected version %d but found %d",
+		e.Key, e.Expected, e.Actual)
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
+
+// versionPrefix holds the last version written under every key, so that
+// the versions keep increasing once a document is deleted or expired.
+// The records are kept when the document is removed by Delete, DeleteByIds,
+// DeleteByQuery or expiry, a key that was written once keeps an eight byte
+// record so that a version read before the delete never matches again
+var versionPrefix = []byte("\x00dodod/version/")
+
+func versionKey(key string) []byte {
+	return append(append([]byte{}, versionPrefix...), key...)
+}
+
+// envelopeData returns the json data and the version of an encoded document,
+// records written before versions were introduced have version one
+func envelopeData(data []byte, documentTypeLength uint32) ([]byte, uint64) {
+	jsonDataLength := binary.BigEndian.Uint32(data[4+documentTypeLength : 4+documentTypeLength+4])
+	rest := data[4+documentTypeLength+4:]
+
+	if uint64(len(rest)) == uint64(jsonDataLength)+8 {
+		return rest[:jsonDataLength], binary.BigEndian.Uint64(rest[jsonDataLength:])
+	}
+
+	return rest, 1
+}
+
+// DecodeDocumentVersion returns the version of an encoded document
+func (db *Database) DecodeDocumentVersion(data []byte) (uint64, error) {
+	if len(data) < 8 {
+		return 0, ErrInvalidData
+	}
+
+	documentTypeLength := binary.BigEndian.Uint32(data[0:4])
+	if len(data) < (8 + int(documentTypeLength)) {
+		return 0, ErrInvalidData
+	}
+
+	_, version := envelopeData(data, documentTypeLength)
+	return version, nil
+}
+
+// storedVersion returns the version of the document stored under the key,
+// zero if there is no document
+func (db *Database) storedVersion(txn *badger.Txn, key string) (uint64, error) {
+	item, err := txn.Get([]byte(key))
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return db.DecodeDocumentVersion(value)
+}
+
+// nextVersion returns the version of the next write under the key, it is
+// greater than every version written before even if the document was deleted
+func (db *Database) nextVersion(txn *badger.Txn, key string) (uint64, error) {
+	version, err := db.storedVersion(txn, key)
+	if err != nil {
+		return 0, err
+	}
+
+	item, err := txn.Get(versionKey(key))
+	if err == badger.ErrKeyNotFound {
+		return version + 1, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(value) == 8 && binary.BigEndian.Uint64(value) > version {
+		version = binary.BigEndian.Uint64(value)
+	}
+
+	return version + 1, nil
+}
+
+// setLastVersion records the version written under the key
+func setLastVersion(w entrySetter, key string, version uint64) error {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, version)
+	return w.SetEntry(badger.NewEntry(versionKey(key), value))
+}
+
+// GetVersion returns the version of the document stored under the key
+func (db *Database) GetVersion(key string) (uint64, error) {
+	if !db.IsDatabaseReady() {
+		return 0, ErrDatabaseIsNotOpen
+	}
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	if _, err := txn.Get([]byte(key)); err == badger.ErrKeyNotFound {
+		return 0, ErrDocumentNotFound
+	} else if err != nil {
+		return 0, err
+	}
+
+	return db.storedVersion(txn, key)
+}
+
+// ReadVersioned reads the documents along with their versions using the keys
+func (db *Database) ReadVersioned(keys []string) (uint64, []*VersionedDocument, error) {
+	return db.ReadVersionedContext(context.Background(), keys)
+}
+
+// ReadVersionedContext is the context aware variant of ReadVersioned
+func (db *Database) ReadVersionedContext(ctx context.Context, keys []string) (uint64, []*VersionedDocument, error) {
+	if !db.IsDatabaseReady() {
+		return 0, nil, ErrDatabaseIsNotOpen
+	}
+
+	txn := db.internalDb.NewTransaction(false)
+	defer txn.Discard()
+
+	output := make([]*VersionedDocument, 0, len(keys))
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+
+		if key == "" {
+			continue
+		}
+
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			continue
+		}
+
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			continue
+		}
+
+		doc, err := db.DecodeDocument(value)
+		if err != nil {
+			continue
+		}
+
+		version, _ := db.DecodeDocumentVersion(value)
+		output = append(output, &VersionedDocument{Key: key, Version: version, Document: doc})
+	}
+
+	return uint64(len(output)), output, nil
+}
+
+// UpdateIfVersion stores the document only if the stored document has the
+// expected version, version zero expects that there is no stored document.
+// Documents stored before versions were introduced have version one.
+// A mismatch or a concurrent write of the same document results in a
+// VersionConflictError. It returns the new version of the document
+func (db *Database) UpdateIfVersion(d interface{}, version uint64) (uint64, error) {
+	return db.UpdateIfVersionContext(context.Background(), d, version)
+}
+
+// UpdateIfVersionContext is the context aware variant of UpdateIfVersion
+func (db *Database) UpdateIfVersionContext(ctx context.Context, d interface{}, version uint64) (uint64, error) {
+	db.indexLock.RLock()
+	defer db.indexLock.RUnlock()
+
+	if !db.IsDatabaseReady() {
+		return 0, ErrDatabaseIsNotOpen
+	}
+
+	document, ok := d.(Document)
+	if !ok {
+		return 0, ErrInvalidDocument
+	}
+
+	if document.GetId() == "" {
+		return 0, ErrIdCanNotBeEmpty
+	}
+
+	key := db.documentKey(document)
+
+	internalBatchTxn := db.internalDb.NewTransaction(true)
+	defer internalBatchTxn.Discard()
+
+	// the read puts the key into the conflict set of the transaction,
+	// so a concurrent write fails the commit
+	actual, err := db.storedVersion(internalBatchTxn, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if version == 0 {
+		if _, err := internalBatchTxn.Get([]byte(key)); err == nil {
+			return 0, &VersionConflictError{Key: key, Expected: version, Actual: actual}
+		}
+	} else if actual != version {
+		return 0, &VersionConflictError{Key: key, Expected: version, Actual: actual}
+	}
+
+	next, err := db.nextVersion(internalBatchTxn, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := db.putDocumentWithVersion(internalBatchTxn, key, d, next); err != nil {
+		return 0, err
+	}
+
+	batch := db.internalIndex.NewBatch()
+	if err := batch.Index(key, indexValue(d)); err != nil {
+		return 0, err
+	}
+
+	intentKey, err := db.writeIndexIntent(internalBatchTxn, []string{key})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if err := internalBatchTxn.Commit(); err == badger.ErrConflict {
+		current, _ := db.GetVersion(key)
+		return 0, &VersionConflictError{Key: key, Expected: version, Actual: current}
+	} else if err != nil {
+		return 0, ErrDatabaseTransactionFailed
+	}
+
+	// the intent stays in the database on failure and
+	// will be replayed by ReplayIntentLog
+	if err := db.internalIndex.Batch(batch); err != nil {
+		return next, ErrIndexStoreTransactionFailed
+	}
+
+	db.clearIndexIntent(intentKey)
+
+	return next, nil
+}
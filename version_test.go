@@ -0,0 +1,156 @@
+package dodod
+
+import (
+	"errors"
+	"github.com/dgraph-io/badger/v2"
+	"testing"
+)
+
+func TestDatabase_Version(t *testing.T) {
+	t.Helper()
+
+	dbPath := "/tmp/dodod"
+	defer cleanupDb(t, dbPath)
+
+	db := &Database{}
+	db.SetupDefaults()
+	db.SetDbPath(dbPath)
+
+	if err := db.RegisterDocument(&MyTestDocument{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := db.UpdateIfVersion(&MyTestDocument{Id: "1"}, 0); err != ErrDatabaseIsNotOpen {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create([]interface{}{&MyTestDocument{Id: "1", Name: "first"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.Update([]interface{}{&MyTestDocument{Id: "1", Name: "second"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if version, err := db.GetVersion("1"); err != nil || version != 2 {
+		t.Fatalf("expected version 2, found: %d, error: %v", version, err)
+	}
+
+	if _, err := db.GetVersion("missing"); err != ErrDocumentNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if total, docs, err := db.ReadVersioned([]string{"1", "missing"}); err != nil || total != 1 {
+		t.Fatalf("expected 1 document, found: %d, error: %v", total, err)
+	} else if docs[0].Version != 2 || docs[0].Document.(*MyTestDocument).Name != "second" {
+		t.Fatalf("unexpected document: %+v", docs[0])
+	}
+
+	t.Run("Compare and swap", func(t *testing.T) {
+		if version, err := db.UpdateIfVersion(&MyTestDocument{Id: "1", Name: "third"}, 2); err != nil || version != 3 {
+			t.Fatalf("expected version 3, found: %d, error: %v", version, err)
+		}
+
+		_, err := db.UpdateIfVersion(&MyTestDocument{Id: "1", Name: "stale"}, 2)
+		var conflictError *VersionConflictError
+		if !errors.As(err, &conflictError) || conflictError.Expected != 2 || conflictError.Actual != 3 {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("error should wrap ErrVersionConflict")
+		}
+
+		if _, docs, _ := db.Read([]string{"1"}); docs[0].(*MyTestDocument).Name != "third" {
+			t.Fatalf("stale update should not be stored")
+		}
+	})
+
+	t.Run("Create only", func(t *testing.T) {
+		if version, err := db.UpdateIfVersion(&MyTestDocument{Id: "2"}, 0); err != nil || version != 1 {
+			t.Fatalf("expected version 1, found: %d, error: %v", version, err)
+		}
+		if !db.IsIndexExists("2") {
+			t.Fatalf("document should be indexed")
+		}
+		if _, err := db.UpdateIfVersion(&MyTestDocument{Id: "2"}, 0); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Records without version", func(t *testing.T) {
+		data, err := db.EncodeDocument(&MyTestDocument{Id: "3", Name: "legacy"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := db.GetInternalDatabase().Update(func(txn *badger.Txn) error {
+			return txn.Set([]byte("3"), data)
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if version, err := db.GetVersion("3"); err != nil || version != 1 {
+			t.Fatalf("expected version 1, found: %d, error: %v", version, err)
+		}
+		if total, _, _ := db.Read([]string{"3"}); total != 1 {
+			t.Fatalf("record without version should be readable")
+		}
+		if version, err := db.UpdateIfVersion(&MyTestDocument{Id: "3", Name: "updated"}, 1); err != nil || version != 2 {
+			t.Fatalf("expected version 2, found: %d, error: %v", version, err)
+		}
+	})
+
+	t.Run("Delete and create again", func(t *testing.T) {
+		if err := db.Delete([]interface{}{&MyTestDocument{Id: "2"}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if version, err := db.UpdateIfVersion(&MyTestDocument{Id: "2"}, 0); err != nil || version != 2 {
+			t.Fatalf("expected version 2, found: %d, error: %v", version, err)
+		}
+
+		// the version read before the delete must not match the new document
+		if _, err := db.UpdateIfVersion(&MyTestDocument{Id: "2"}, 1); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Version records are kept", func(t *testing.T) {
+		if err := db.Create([]interface{}{&MyTestDocument{Id: "4"}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := db.DeleteByIds("MyTestDocument", []string{"4"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := db.GetInternalDatabase().View(func(txn *badger.Txn) error {
+			_, err := txn.Get(versionKey("4"))
+			return err
+		}); err != nil {
+			t.Fatalf("version record should be kept, error: %v", err)
+		}
+
+		if total, err := db.Count(""); err != nil || total != 3 {
+			t.Fatalf("version records should not be counted, found: %d, error: %v", total, err)
+		}
+
+		if version, err := db.UpdateIfVersion(&MyTestDocument{Id: "4"}, 0); err != nil || version != 2 {
+			t.Fatalf("expected version 2, found: %d, error: %v", version, err)
+		}
+	})
+
+	t.Run("Commit conflict", func(t *testing.T) {
+		if err := commitError(badger.ErrConflict); err != badger.ErrConflict {
+			t.Fatalf("conflict should be returned as is, found: %v", err)
+		}
+		if err := commitError(badger.ErrTxnTooBig); err != ErrDatabaseTransactionFailed {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("error occured while closing, error: %v", err)
+	}
+}
@@ -23,8 +23,46 @@ var ErrIndexStoreTransactionFailed = errors.New("dodod: index store transaction
 
 var ErrIdCanNotBeEmpty = errors.New("dodod: id can not be empty")
 
+var ErrIdIsReserved = errors.New("dodod: id is reserved for internal use")
+
+var ErrFieldIsNotIndexed = errors.New("dodod: field is not indexed")
+
+var ErrUniqueConstraintViolation = errors.New("dodod: unique constraint violation")
+
+var ErrVersionConflict = errors.New("dodod: version conflict")
+
+var ErrDocumentNotFound = errors.New("dodod: document not found")
+
+var ErrDocumentAlreadyExists = errors.New("dodod: document already exists")
+
+var ErrInvalidOutputType = errors.New("dodod: invalid output type")
+
+var ErrInvalidUpdateFunction = errors.New("dodod: invalid update function")
+
+var ErrIdCanNotBeChanged = errors.New("dodod: id can not be changed")
+
+var ErrInvalidBatch = errors.New("dodod: batch contains invalid elements")
+
+var ErrDocumentIsExpired = errors.New("dodod: document is expired")
+
+var ErrInvalidCallback = errors.New("dodod: invalid callback")
+
+var ErrInvalidPatch = errors.New("dodod: invalid patch")
+
 var ErrDatabaseIsNotOpen = errors.New("dodod: database is not open")
 
+var ErrDatabaseIsReadOnly = errors.New("dodod: database is read only")
+
+var ErrDatabaseIsOpen = errors.New("dodod: database is open")
+
+var ErrDatabaseExists = errors.New("dodod: database already exists")
+
+var ErrDatabaseNotFound = errors.New("dodod: database not found")
+
+var ErrInvalidBackup = errors.New("dodod: invalid backup")
+
+var ErrInvalidImportRecord = errors.New("dodod: invalid import record")
+
 // ErrFieldTypeMismatch will occur if the field already registered as different type
 var ErrFieldTypeMismatch = errors.New("dodod: field type mismatch")
 
@@ -42,6 +80,12 @@ var ErrInvalidConfigFile = errors.New("dodod: invalid config file")
 
 var ErrJSONParseFailed = errors.New("dodod: failed to parse json data")
 
+var ErrInvalidSearchRequest = errors.New("dodod: invalid search request")
+
+var ErrInvalidIterateOptions = errors.New("dodod: invalid iterate options")
+
+var ErrInvalidCursor = errors.New("dodod: invalid cursor")
+
 //var ErrInvalidBase = errors.New(`dodod: invalid base`)
 //
 //var ErrInvalidDoc = errors.New(`dodod: invalid doc, nil pointer`)